@@ -17,14 +17,21 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/Trendyol/gaos/executor"
+	"github.com/Trendyol/gaos/graph"
 	"github.com/Trendyol/gaos/logger"
 	"github.com/Trendyol/gaos/runner"
+	"github.com/Trendyol/gaos/scaffold"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 func Execute(version, builtBy, date, commit string) {
@@ -39,10 +46,26 @@ func Execute(version, builtBy, date, commit string) {
 	)
 
 	var config executor.Config
-	var scenario, execute string
+	var logs logger.Config
+	var scenario, execute, tracing, output, format string
+	var management int32
+	var route, start string
+	var simulation runner.Simulation
 
 	var cmd = &cobra.Command{
 		Use: "gaos",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+
+			err := logger.Configure(logs)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			_ = logger.Close()
+		},
 	}
 
 	var runCmd = &cobra.Command{
@@ -60,6 +83,18 @@ func Execute(version, builtBy, date, commit string) {
 				return
 			}
 
+			if management > 0 {
+				gaos.Management.Port = management
+			}
+
+			if len(tracing) > 0 {
+				if gaos.Tracing == nil {
+					gaos.Tracing = &runner.Tracing{}
+				}
+
+				gaos.Tracing.Endpoint = tracing
+			}
+
 			if len(execute) > 0 {
 				gaos.Run(strings.Split(execute, ",")...)
 			} else {
@@ -93,6 +128,57 @@ func Execute(version, builtBy, date, commit string) {
 		},
 	}
 
+	var initCmd = &cobra.Command{
+		Use:   "init",
+		Args:  cobra.NoArgs,
+		Short: "Create a scenario file interactively",
+		Long:  "Create a commented scenario file by answering questions about services, paths and chaos behaviours",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			err := scaffold.Run(output)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+				return
+			}
+		},
+	}
+
+	var graphCmd = &cobra.Command{
+		Use:   "graph",
+		Args:  cobra.NoArgs,
+		Short: "Render the scenario transition graph",
+		Long:  "Render services, routes, scenarios and their accept/ignore transitions as DOT, Mermaid or SVG",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			err := render(scenario, format, output)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+				return
+			}
+		},
+	}
+
+	var explainCmd = &cobra.Command{
+		Use:   "explain",
+		Args:  cobra.NoArgs,
+		Short: "Simulate requests to a route on a virtual clock",
+		Long:  "Print the scenario, triggered executable, response and transition of each simulated request to a route without waiting for latencies and durations",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			err := explain(scenario, route, start, simulation)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+				return
+			}
+		},
+	}
+
 	var versionCmd = &cobra.Command{
 		Use:   "version",
 		Short: "Print the version number of Gaos",
@@ -101,16 +187,40 @@ func Execute(version, builtBy, date, commit string) {
 		},
 	}
 
+	//log flags
+	cmd.PersistentFlags().StringVarP(&logs.Level, "log-level", "", "info", "minimum log level {trace, debug, info, warn, error}")
+	cmd.PersistentFlags().StringVarP(&logs.Format, "log-format", "", "text", "log format {text, json}")
+	cmd.PersistentFlags().StringVarP(&logs.Output, "log-output", "", "stdout", "log output {stdout, stderr, <file path>}")
+
 	//run flags
 	runCmd.Flags().StringVarP(&execute, "execute", "x", "", "execute scenario services")
-	runCmd.Flags().StringVarP(&scenario, "scenario", "s", "./scenario.json", "scenario file input")
+	runCmd.Flags().StringVarP(&scenario, "scenario", "s", "./scenario.json", "scenario file or directory input")
+	runCmd.Flags().Int32VarP(&management, "management-port", "m", 0, "management port for metrics and admin endpoints, 9090 unless set in the scenario")
+	runCmd.Flags().StringVar(&tracing, "tracing-endpoint", "", "OTLP/HTTP endpoint traces are exported to, e.g. localhost:4318")
+
+	//init flags
+	initCmd.Flags().StringVarP(&output, "output", "o", "./scenario.json", "scenario file output")
+
+	//graph flags
+	graphCmd.Flags().StringVarP(&scenario, "scenario", "s", "./scenario.json", "scenario file or directory input")
+	graphCmd.Flags().StringVarP(&format, "format", "f", graph.FormatDot, "graph format {dot, mermaid, svg}")
+	graphCmd.Flags().StringVarP(&output, "output", "o", "", "graph file output, stdout if not given")
+
+	//explain flags
+	explainCmd.Flags().StringVarP(&scenario, "scenario", "s", "./scenario.json", "scenario file or directory input")
+	explainCmd.Flags().StringVar(&route, "route", "", "route to simulate as <service>:<path>, e.g. search:/api/products/42")
+	explainCmd.Flags().IntVarP(&simulation.Requests, "requests", "n", 10, "number of simulated requests")
+	explainCmd.Flags().DurationVarP(&simulation.Interval, "interval", "i", 0, "virtual time between requests, e.g. 500ms")
+	explainCmd.Flags().StringVar(&start, "start", "", "virtual time of the first request in RFC 3339, now if not given")
+	explainCmd.Flags().StringVar(&simulation.Subject, "subject", "", "client certificate subject of the requests")
 
 	//start flags
 	startCmd.Flags().StringVarP(&config.Environment, "environment", "e", "local", "gaos running environment {docker, k8s}")
-	startCmd.Flags().StringVarP(&config.Scenario, "scenario", "s", "./scenario.json", "scenario file input")
+	startCmd.Flags().StringVarP(&config.Scenario, "scenario", "s", "./scenario.json", "scenario file or directory input")
 	startCmd.Flags().IntVarP(&config.ContinueOnFailure, "--continue-on-failure", "y", 0, "continue on failure {0: ask prompt, 1: continue on failure, 2: break on failure}. default: 0")
 
 	//docker environment flags
+	startCmd.Flags().StringArrayVarP(&config.Env, "env", "E", nil, "environment variable interpolated into the scenario file in containers, e.g. -E UPSTREAM=http://payment:8080")
 	startCmd.Flags().StringVarP(&config.Timeout, "timeout", "t", "5m", "client timeout")
 	startCmd.Flags().StringVarP(&config.Registry, "registry", "r", "", "image registry")
 	startCmd.Flags().StringVarP(&config.Username, "username", "u", "", "image registry username")
@@ -124,9 +234,119 @@ func Execute(version, builtBy, date, commit string) {
 	startCmd.Flags().StringVarP(&config.Secret, "secret", "", "", "secret key name")
 	startCmd.Flags().StringVarP(&config.Replica, "replica", "", "1", "replica count")
 
-	cmd.AddCommand(runCmd, startCmd, versionCmd)
+	cmd.AddCommand(runCmd, startCmd, initCmd, graphCmd, explainCmd, ctlCommand(), loadCommand(), experimentCommand(), versionCmd)
 
 	cmd.SetVersionTemplate(info)
 
 	_ = cmd.Execute()
 }
+
+// render writes the graph of the scenario to output, or to stdout. Missing and unreachable scenarios are
+// highlighted in the graph, and also logged when writing to a file.
+func render(scenario, format, output string) error {
+
+	g, err := runner.Read(scenario)
+
+	if err != nil {
+		return err
+	}
+
+	gr, err := graph.Build(g)
+
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+
+	err = gr.Write(&b, format)
+
+	if err != nil {
+		return err
+	}
+
+	if len(output) == 0 {
+		_, err = os.Stdout.Write(b.Bytes())
+		return err
+	}
+
+	err = ioutil.WriteFile(output, b.Bytes(), 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "Graph file can not written: %s", output)
+	}
+
+	for _, name := range gr.Missing() {
+		logger.Warn(fmt.Sprintf("Scenario [%s] is referenced but not defined", name))
+	}
+
+	for _, name := range gr.Unreachable() {
+		logger.Warn(fmt.Sprintf("Scenario [%s] is unreachable", name))
+	}
+
+	logger.Info(fmt.Sprintf("Graph written: %s", output))
+
+	return nil
+}
+
+// explain simulates requests to a `<service>:<path>` route and prints a row per request.
+func explain(scenario, route, start string, simulation runner.Simulation) error {
+
+	i := strings.Index(route, ":")
+
+	if i <= 0 {
+		return errors.Errorf("Route must be given as <service>:<path>: %s", route)
+	}
+
+	if len(start) > 0 {
+
+		t, err := time.Parse(time.RFC3339, start)
+
+		if err != nil {
+			return errors.Wrapf(err, "Start time can not parsed: %s", start)
+		}
+
+		simulation.Start = t
+	}
+
+	g, err := runner.Read(scenario)
+
+	if err != nil {
+		return err
+	}
+
+	steps, err := g.Explain(route[:i], route[i+1:], simulation)
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "#\tTIME\tSCENARIO\tIGNORED BY\tACTION\tSTATUS\tBODY\tTRANSITION")
+
+	for _, s := range steps {
+
+		status := "upstream"
+
+		if s.Status > 0 {
+			status = fmt.Sprint(s.Status)
+		}
+
+		trigger := "-"
+
+		if len(s.Trigger) > 0 {
+			trigger = fmt.Sprintf("%s (%s)", s.Trigger, s.Reason)
+		}
+
+		transition := "-"
+
+		if len(s.Transition) > 0 {
+			transition = "-> " + s.Transition
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t+%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Request, s.Time.Sub(steps[0].Time), s.Scenario, trigger, s.Action, status, s.Body, transition)
+	}
+
+	return w.Flush()
+}
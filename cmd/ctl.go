@@ -0,0 +1,213 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/Trendyol/gaos/ctl"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/Trendyol/gaos/runner"
+	"github.com/spf13/cobra"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+type journalFlags struct {
+	service, route, scenario, action, status string
+	tail                                     int
+	follow                                   bool
+	interval                                 time.Duration
+}
+
+// ctlCommand returns `gaos ctl`, which drives a running instance through its management port.
+func ctlCommand() *cobra.Command {
+
+	var address string
+	var journal journalFlags
+
+	run := func(f func(c *ctl.Client, args []string) error) func(cmd *cobra.Command, args []string) {
+		return func(cmd *cobra.Command, args []string) {
+
+			err := f(ctl.New(address), args)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	var ctlCmd = &cobra.Command{
+		Use:   "ctl",
+		Short: "Control a running Gaos instance",
+		Long:  "Inspect and change a running Gaos instance through its management port",
+	}
+
+	var servicesCmd = &cobra.Command{
+		Use:     "services",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		Short:   "List services, routes, active scenarios and request counters",
+		Run: run(func(c *ctl.Client, _ []string) error {
+
+			services, err := c.Services()
+
+			if err != nil {
+				return err
+			}
+
+			return printServices(services)
+		}),
+	}
+
+	var switchCmd = &cobra.Command{
+		Use:   "switch <service> <route> <scenario>",
+		Args:  cobra.ExactArgs(3),
+		Short: "Switch a route to another scenario",
+		Run: run(func(c *ctl.Client, args []string) error {
+
+			err := c.SetScenario(args[0], args[1], args[2])
+
+			if err != nil {
+				return err
+			}
+
+			logger.Info(fmt.Sprintf("[%s] Scenario of %s switched to [%s]", args[0], args[1], args[2]))
+
+			return nil
+		}),
+	}
+
+	var resetCmd = &cobra.Command{
+		Use:   "reset",
+		Args:  cobra.NoArgs,
+		Short: "Switch routes back to their scenarios and restart counters",
+		Run: run(func(c *ctl.Client, _ []string) error {
+
+			err := c.Reset()
+
+			if err != nil {
+				return err
+			}
+
+			logger.Info("Routes are reset to their scenarios")
+
+			return nil
+		}),
+	}
+
+	var journalCmd = &cobra.Command{
+		Use:   "journal",
+		Args:  cobra.NoArgs,
+		Short: "Print received requests, and follow new ones with -f",
+		Run: run(func(c *ctl.Client, _ []string) error {
+			return tailJournal(c, journal)
+		}),
+	}
+
+	ctlCmd.PersistentFlags().StringVarP(&address, "address", "a", fmt.Sprintf("localhost:%d", runner.DefaultManagementPort), "management address of the running instance")
+
+	journalCmd.Flags().StringVar(&journal.service, "service", "", "only requests of the service")
+	journalCmd.Flags().StringVar(&journal.route, "route", "", "only requests of the route template")
+	journalCmd.Flags().StringVar(&journal.scenario, "scenario", "", "only requests handled by the scenario")
+	journalCmd.Flags().StringVar(&journal.action, "action", "", "only requests which executed the action {accept, ignore}")
+	journalCmd.Flags().StringVar(&journal.status, "status", "", "only requests responded with the status")
+	journalCmd.Flags().IntVarP(&journal.tail, "tail", "n", 10, "number of recorded requests to print first, all if 0")
+	journalCmd.Flags().BoolVarP(&journal.follow, "follow", "f", false, "keep printing new requests")
+	journalCmd.Flags().DurationVarP(&journal.interval, "interval", "i", time.Second, "polling interval while following")
+
+	ctlCmd.AddCommand(servicesCmd, switchCmd, resetCmd, journalCmd)
+
+	return ctlCmd
+}
+
+func printServices(services []runner.ServiceState) error {
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "SERVICE\tTYPE\tPORT\tMETHOD\tROUTE\tSCENARIO\tREQUESTS\tACCEPTED\tIGNORED")
+
+	for _, s := range services {
+		for _, r := range s.Routes {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%d\t%d\t%d\n", s.Name, s.Type, s.Port, dash(r.Method), r.Route, r.Scenario, r.Requests, r.Accepted, r.Ignored)
+		}
+	}
+
+	return w.Flush()
+}
+
+func tailJournal(c *ctl.Client, f journalFlags) error {
+
+	filters := url.Values{}
+
+	for k, v := range map[string]string{"service": f.service, "route": f.route, "scenario": f.scenario, "action": f.action, "status": f.status} {
+		if len(v) > 0 {
+			filters.Set(k, v)
+		}
+	}
+
+	entries, err := c.Journal(filters, 0)
+
+	if err != nil {
+		return err
+	}
+
+	if f.tail > 0 && len(entries) > f.tail {
+		entries = entries[len(entries)-f.tail:]
+	}
+
+	var since int64
+
+	for {
+		for _, e := range entries {
+			fmt.Printf("%d %s [%s] %s %s -> %s %s %d %dms\n", e.Id, e.Time.Format(time.RFC3339), e.Service, e.Method, pathWithQuery(e), e.Scenario, e.Action, e.Status, e.Elapsed/time.Millisecond)
+			since = e.Id
+		}
+
+		if !f.follow {
+			return nil
+		}
+
+		time.Sleep(f.interval)
+
+		entries, err = c.Journal(filters, since)
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func pathWithQuery(e runner.Entry) string {
+
+	if len(e.Query) == 0 {
+		return e.Path
+	}
+
+	return e.Path + "?" + e.Query
+}
+
+func dash(v string) string {
+
+	if len(v) == 0 {
+		return "-"
+	}
+
+	return v
+}
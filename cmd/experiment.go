@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/Trendyol/gaos/runner"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// experimentCommand returns `gaos experiment`, which runs the services of a scenario through the phases
+// of one of its experiments.
+func experimentCommand() *cobra.Command {
+
+	var scenario, execute string
+	var management int32
+
+	var experimentCmd = &cobra.Command{
+		Use:   "experiment",
+		Short: "Run timed chaos experiments",
+		Long:  "Run the services of a scenario file and switch their routes through the phases of an experiment",
+	}
+
+	var runCmd = &cobra.Command{
+		Use:   "run [experiment]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Run an experiment, the only one defined if no name is given",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			name := ""
+
+			if len(args) > 0 {
+				name = args[0]
+			}
+
+			err := runExperiment(scenario, execute, management, name)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+				return
+			}
+		},
+	}
+
+	runCmd.Flags().StringVarP(&scenario, "scenario", "s", "./scenario.json", "scenario file or directory input")
+	runCmd.Flags().StringVarP(&execute, "execute", "x", "", "execute scenario services")
+	runCmd.Flags().Int32VarP(&management, "management-port", "m", 0, "management port for metrics and admin endpoints, 9090 unless set in the scenario")
+
+	experimentCmd.AddCommand(runCmd)
+
+	return experimentCmd
+}
+
+// runExperiment serves the scenario until the experiment ends or the process is interrupted. The experiment
+// is validated before servers start.
+func runExperiment(scenario, execute string, management int32, name string) error {
+
+	g, err := runner.New(scenario)
+
+	if err != nil {
+		return err
+	}
+
+	err = g.ValidateExperiment(name)
+
+	if err != nil {
+		return err
+	}
+
+	if management > 0 {
+		g.Management.Port = management
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var services []string
+
+	if len(execute) > 0 {
+		services = strings.Split(execute, ",")
+	}
+
+	_, err = g.Start(ctx, services...)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		logger.Info("Servers are stopping...")
+
+		_ = g.Shutdown()
+	}()
+
+	return g.RunExperiment(ctx, name)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/Trendyol/gaos/load"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/Trendyol/gaos/runner"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type loadFlags struct {
+	header   []string
+	body     string
+	format   string
+	scenario string
+	execute  string
+	switches []string
+}
+
+// loadCommand returns `gaos load`, which can run the scenario in the same process and switch its routes
+// while sending requests.
+func loadCommand() *cobra.Command {
+
+	var config load.Config
+	var flags loadFlags
+
+	var loadCmd = &cobra.Command{
+		Use:   "load <url>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Generate HTTP load against a URL",
+		Long:  "Send requests at a constant or ramping rate, optionally switching scenarios of in-process services, and report latency percentiles, statuses, timeouts and errors",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			config.URL = args[0]
+
+			err := runLoad(config, flags)
+
+			if err != nil {
+				logger.Error(err)
+				os.Exit(1)
+				return
+			}
+		},
+	}
+
+	loadCmd.Flags().StringVarP(&config.Method, "method", "X", "GET", "request method")
+	loadCmd.Flags().StringArrayVarP(&flags.header, "header", "H", nil, "request header, e.g. -H 'Content-Type: application/json'")
+	loadCmd.Flags().StringVarP(&flags.body, "body", "b", "", "request body, or @<file path> to read it from a file")
+	loadCmd.Flags().StringVar(&config.Profile, "profile", load.ProfileConstant, "rate profile {constant, ramp}")
+	loadCmd.Flags().Float64VarP(&config.Rate, "rate", "r", 10, "requests per second, the final rate of a ramp")
+	loadCmd.Flags().Float64Var(&config.RampFrom, "ramp-from", 0, "requests per second a ramp starts from")
+	loadCmd.Flags().DurationVarP(&config.Duration, "duration", "d", 10*time.Second, "load duration")
+	loadCmd.Flags().DurationVarP(&config.Timeout, "timeout", "t", load.DefaultTimeout, "request timeout")
+	loadCmd.Flags().IntVarP(&config.Concurrency, "concurrency", "c", load.DefaultConcurrency, "maximum requests in flight")
+	loadCmd.Flags().StringVarP(&flags.format, "format", "f", load.FormatText, "report format {text, json}")
+	loadCmd.Flags().StringVarP(&flags.scenario, "scenario", "s", "", "scenario file or directory to run in the same process")
+	loadCmd.Flags().StringVarP(&flags.execute, "execute", "x", "", "execute scenario services")
+	loadCmd.Flags().StringArrayVar(&flags.switches, "switch", nil, "switch a route of the scenario at an offset, e.g. --switch 10s=search:/api/{id}:error")
+
+	return loadCmd
+}
+
+func runLoad(config load.Config, flags loadFlags) error {
+
+	config.Header = map[string]string{}
+
+	for _, h := range flags.header {
+
+		kv := strings.SplitN(h, ":", 2)
+
+		if len(kv) != 2 {
+			return errors.Errorf("Header must be in `name: value` form. Value: %s", h)
+		}
+
+		config.Header[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	if strings.HasPrefix(flags.body, "@") {
+
+		body, err := ioutil.ReadFile(flags.body[1:])
+
+		if err != nil {
+			return errors.Wrapf(err, "Body file can not read: %s", flags.body[1:])
+		}
+
+		config.Body = body
+	} else {
+		config.Body = []byte(flags.body)
+	}
+
+	if len(flags.switches) > 0 && len(flags.scenario) == 0 {
+		return errors.New("Scenario must be given to switch routes")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var events []load.Event
+
+	if len(flags.scenario) > 0 {
+
+		g, err := runner.Read(flags.scenario)
+
+		if err != nil {
+			return err
+		}
+
+		var services []string
+
+		if len(flags.execute) > 0 {
+			services = strings.Split(flags.execute, ",")
+		}
+
+		_, err = g.Start(ctx, services...)
+
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = g.Shutdown()
+		}()
+
+		for _, s := range flags.switches {
+
+			e, err := switchEvent(g, s)
+
+			if err != nil {
+				return err
+			}
+
+			events = append(events, e)
+		}
+	}
+
+	report, err := load.Run(ctx, config, events...)
+
+	if err != nil {
+		return err
+	}
+
+	return report.Write(os.Stdout, flags.format)
+}
+
+// switchEvent parses `<offset>=<service>:<route>:<scenario>` into an event switching the route.
+func switchEvent(g *runner.Runner, v string) (load.Event, error) {
+
+	kv := strings.SplitN(v, "=", 2)
+
+	if len(kv) != 2 {
+		return load.Event{}, errors.Errorf("Switch must be in `<offset>=<service>:<route>:<scenario>` form. Value: %s", v)
+	}
+
+	at, err := time.ParseDuration(kv[0])
+
+	if err != nil {
+		return load.Event{}, errors.Wrapf(err, "Switch offset can not parsed. Value: %s", v)
+	}
+
+	i := strings.Index(kv[1], ":")
+	j := strings.LastIndex(kv[1], ":")
+
+	if i <= 0 || j <= i+1 || j == len(kv[1])-1 {
+		return load.Event{}, errors.Errorf("Switch must be in `<offset>=<service>:<route>:<scenario>` form. Value: %s", v)
+	}
+
+	service, route, scenario := kv[1][:i], kv[1][i+1:j], kv[1][j+1:]
+
+	if _, ok := g.Scenario[scenario]; !ok {
+		return load.Event{}, errors.Errorf("Scenario [%s] is not defined", scenario)
+	}
+
+	return load.Event{
+		At:   at,
+		Name: fmt.Sprintf("%s:%s -> %s", service, route, scenario),
+		Fire: func() error {
+			return g.SetScenario(service, route, scenario)
+		},
+	}, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ctl is a client of the management port of a running Gaos instance.
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Trendyol/gaos/runner"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultTimeout = 5 * time.Second
+
+type Client struct {
+	address string
+	client  *fasthttp.Client
+	timeout time.Duration
+}
+
+// New returns a client of the management port at address, e.g. `localhost:9090` or `http://gaos:9090`.
+func New(address string) *Client {
+
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	return &Client{
+		address: strings.TrimSuffix(address, "/"),
+		client:  &fasthttp.Client{},
+		timeout: DefaultTimeout,
+	}
+}
+
+// Services returns the running services, their routes and active scenarios.
+func (c *Client) Services() ([]runner.ServiceState, error) {
+
+	var services []runner.ServiceState
+
+	err := c.do(fasthttp.MethodGet, "/services", nil, &services)
+
+	return services, err
+}
+
+// SetScenario switches the scenario of a running route.
+func (c *Client) SetScenario(service, route, scenario string) error {
+
+	body, err := json.Marshal(runner.ScenarioChange{Route: route, Scenario: scenario})
+
+	if err != nil {
+		return errors.Wrap(err, "Scenario change marshalling error")
+	}
+
+	return c.do(fasthttp.MethodPut, fmt.Sprintf("/services/%s/scenario", url.PathEscape(service)), body, nil)
+}
+
+// Reset switches every route back to its configured scenario and restarts counters.
+func (c *Client) Reset() error {
+	return c.do(fasthttp.MethodPost, "/reset", nil, nil)
+}
+
+// Journal returns the journal entries matching the filters, recorded after the entry with id since.
+func (c *Client) Journal(filters url.Values, since int64) ([]runner.Entry, error) {
+
+	q := url.Values{}
+
+	for k, v := range filters {
+		q[k] = v
+	}
+
+	if since > 0 {
+		q.Set("since", strconv.FormatInt(since, 10))
+	}
+
+	var entries []runner.Entry
+
+	err := c.do(fasthttp.MethodGet, "/journal?"+q.Encode(), nil, &entries)
+
+	return entries, err
+}
+
+func (c *Client) do(method, path string, body []byte, v interface{}) error {
+
+	req := fasthttp.AcquireRequest()
+	res := fasthttp.AcquireResponse()
+
+	defer fasthttp.ReleaseResponse(res)
+	defer fasthttp.ReleaseRequest(req)
+
+	req.Header.SetMethod(method)
+	req.SetRequestURI(c.address + path)
+
+	if body != nil {
+		req.Header.SetContentType("application/json")
+		req.SetBody(body)
+	}
+
+	err := c.client.DoTimeout(req, res, c.timeout)
+
+	if err != nil {
+		return errors.Wrapf(err, "Management endpoint can not reached: %s", c.address)
+	}
+
+	if res.StatusCode() >= fasthttp.StatusBadRequest {
+
+		var e struct {
+			Message string `json:"message"`
+			Cause   string `json:"cause"`
+		}
+
+		if json.Unmarshal(res.Body(), &e) == nil && len(e.Cause) > 0 {
+			return errors.New(e.Cause)
+		}
+
+		return errors.Errorf("Management endpoint responded %d: %s", res.StatusCode(), res.Body())
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(res.Body(), v)
+
+	if err != nil {
+		return errors.Wrap(err, "Management response can not parsed")
+	}
+
+	return nil
+}
@@ -80,6 +80,111 @@ setup() {
 	[[ $output = *"[9081] Http server closed"* ]]
 }
 
+@test "project: graph: should render scenario" {
+	run ${CMD} graph -s $TEST_SCENARIO_PASS
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 0 ]
+	[[ $output = "digraph gaos {"* ]]
+	[[ $output = *'"scenario:latency" -> "scenario:duration" [label="ignore"'* ]]
+}
+
+@test "project: graph: should not render with bad format" {
+	run ${CMD} graph -s $TEST_SCENARIO_PASS -f png
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Unexpected graph format given: png"* ]]
+}
+
+@test "project: explain: should simulate route" {
+	run ${CMD} explain -s $TEST_SCENARIO_PASS --route search:/api/timezone/Europe/Istanbul -n 4 --log-level info
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 0 ]
+	[[ $output = *"rate (Request count exceed scenario rate limit)"*"-> duration"* ]]
+}
+
+@test "project: explain: should not simulate undefined route" {
+	run ${CMD} explain -s $TEST_SCENARIO_PASS --route search:/api/unknown
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Path [/api/unknown] is not defined for service [search]"* ]]
+}
+
+@test "project: ctl: should not list services without running instance" {
+	run ${CMD} ctl services -a localhost:1
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Management endpoint can not reached"* ]]
+}
+
+@test "project: ctl: should list services on the default management port" {
+	timeout 5 ${CMD} run -s $TEST_SCENARIO_PASS 3>&- &
+	sleep 2
+	run ${CMD} ctl services
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	wait
+	[ "$status" -eq 0 ]
+	[[ $output = *"SERVICE"*"ROUTE"*"SCENARIO"* ]]
+}
+
+@test "project: load: should not switch without scenario" {
+	run ${CMD} load http://localhost:1 --switch 1s=search:/api:error
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Scenario must be given to switch routes"* ]]
+}
+
+@test "project: load: should report a phase per scenario switch" {
+	scenario="$BATS_TMPDIR/gaos-load.json"
+	cat > "$scenario" <<'JSON'
+{
+  "service": { "search": { "port": 9083, "path": { "/api/{id}": { "method": "GET", "scenario": "ok" } } } },
+  "scenario": {
+    "ok": { "accept": { "status": 200, "result": { "type": "static", "content": { "id": 1 } } } },
+    "error": { "accept": { "status": 500, "result": { "type": "static", "content": { "error": "boom" } } } }
+  }
+}
+JSON
+	run ${CMD} load http://localhost:9083/api/1 -s "$scenario" -r 20 -d 2s --switch '1s=search:/api/{id}:error' --log-level warn
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 0 ]
+	[[ $output = *"start "*" +0s "*" 200:"* ]]
+	[[ $output = *"search:/api/{id} -> error "*" +1"*" 500:"* ]]
+	[[ $output = *"total "*"200:"*"500:"* ]]
+}
+
+@test "project: experiment: should not run undefined experiment" {
+	run ${CMD} experiment run unknown -s $TEST_SCENARIO_PASS
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Experiment [unknown] is not defined"* ]]
+}
+
+@test "project: experiment: should not start servers for an invalid experiment" {
+	scenario="$BATS_TMPDIR/gaos-experiment.json"
+	cat > "$scenario" <<'JSON'
+{
+  "service": { "search": { "port": 9084, "path": { "/api": { "method": "GET", "scenario": "ok" } } } },
+  "scenario": { "ok": { "accept": { "status": 200 } } },
+  "experiment": { "outage": { "phases": [{ "duration": "1s", "routes": { "search": { "/api": "error" } } }] } }
+}
+JSON
+	run ${CMD} experiment run outage -s "$scenario"
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Experiment [outage] phase [phase-1] scenario [error] is not defined"* ]]
+	[[ $output != *"server started"* ]]
+}
+
 @test "project: start: should not start without scenario" {
 	run ${CMD} start
 	echo "status = ${status}">&2
@@ -96,6 +201,22 @@ setup() {
 	[[ $output = *"Unexpected environment given"* ]]
 }
 
+@test "project: start: should read services without container-only secrets" {
+	scenario="$BATS_TMPDIR/gaos-secrets.json"
+	cat > "$scenario" <<'JSON'
+{
+  "service": { "payment": { "port": ${PORT:?is required}, "path": { "/payments": { "method": "GET", "scenario": "token" } } } },
+  "scenario": { "token": { "accept": { "result": { "type": "static", "content": { "token": "${file:/run/secrets/gaos-token}" } } } } }
+}
+JSON
+	run ${CMD} start -s "$scenario" -E PORT=8080
+	echo "status = ${status}">&2
+	echo "output = ${output}">&2
+	[ "$status" -eq 1 ]
+	[[ $output = *"Unexpected environment given"* ]]
+	[[ $output != *"can not read"* ]]
+}
+
 @test "project: start: check prerequisites" {
     run command -v kind
     [ "$status" -eq 0 ]
@@ -35,6 +35,7 @@ type Config struct {
 	Secret            string `for:"k8s"`
 	Replica           string `for:"k8s"`
 	ContinueOnFailure int    `for:"all"`
+	Env               []string
 }
 
 func (e *Config) GetMyConfig(my string) map[string]string {
@@ -35,6 +35,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -42,28 +43,28 @@ import (
 
 const dockerfile = `FROM trendyol/gaos:%s
 
-ADD ./scenario.json .
-
-EXPOSE %d  
+%s
+EXPOSE %s
 
 ENTRYPOINT ["./gaos", "run", "-x", "%s"]`
 
 var timeout = 20 * time.Second
 
 type Docker struct {
-	runner            runner.Runner
+	runner            *runner.Runner
 	client            *client.Client
-	scenario          string
+	files             map[string][]byte
 	registry          string
 	username          string
 	password          string
 	timeout           string
 	continueOnFailure string
+	env               []string
 }
 
-func NewDocker(g runner.Runner) (*Docker, error) {
+func NewDocker(g *runner.Runner) (*Docker, error) {
 
-	scenarioJson, err := json.Marshal(g)
+	files, err := bundle(g)
 
 	if err != nil {
 		return nil, err
@@ -76,9 +77,9 @@ func NewDocker(g runner.Runner) (*Docker, error) {
 	}
 
 	engine := &Docker{
-		runner:   g,
-		client:   docker,
-		scenario: string(scenarioJson),
+		runner: g,
+		client: docker,
+		files:  files,
 	}
 
 	return engine, nil
@@ -94,6 +95,16 @@ func (d *Docker) Initialize(c Config) error {
 	d.timeout = config["Timeout"]
 	d.continueOnFailure = config["ContinueOnFailure"]
 
+	env, err := environment(c.Env)
+
+	if err != nil {
+		return errors.Wrap(err, "Docker -> Environment can not parsed")
+	}
+
+	for k, v := range env {
+		d.env = append(d.env, fmt.Sprintf("%s=%s", k, v))
+	}
+
 	if t, err := time.ParseDuration(d.timeout); err == nil {
 		timeout = t
 	} else {
@@ -164,7 +175,19 @@ func (d *Docker) createTar(name string, port int32) (string, error) {
 		return "", errors.Wrap(err, "Docker -> Temp directory can not created")
 	}
 
-	_dockerfile := fmt.Sprintf(dockerfile, runner.VERSION, port, name)
+	add := fmt.Sprintf("ADD ./%s .\n", scenarioFile)
+
+	if len(d.files) > 1 {
+		add += fmt.Sprintf("ADD ./%s ./%s\n", scenarioDir, scenarioDir)
+	}
+
+	expose := fmt.Sprint(port)
+
+	if m := d.runner.Management.Port; m > 0 {
+		expose += fmt.Sprintf(" %d", m)
+	}
+
+	_dockerfile := fmt.Sprintf(dockerfile, runner.VERSION, add, expose, name)
 
 	err = ioutil.WriteFile(fmt.Sprintf("%s/%s", dir, "Dockerfile"), []byte(_dockerfile), 0644)
 
@@ -173,10 +196,19 @@ func (d *Docker) createTar(name string, port int32) (string, error) {
 
 	}
 
-	err = ioutil.WriteFile(fmt.Sprintf("%s/%s", dir, "scenario.json"), []byte(d.scenario), 0644)
+	for name, content := range d.files {
 
-	if err != nil {
-		return "", errors.Wrap(err, "Docker -> Temp scenario.json can not created")
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+
+		if err == nil {
+			err = ioutil.WriteFile(path, content, 0644)
+		}
+
+		if err != nil {
+			return "", errors.Wrapf(err, "Docker -> Temp %s can not created", name)
+		}
 	}
 
 	tarName := fmt.Sprintf("%s.tar", dir)
@@ -291,11 +323,12 @@ func (d *Docker) createImage(name string, port int32) (*Image, error) {
 	}
 
 	result := &Image{
-		Id:       id,
-		Title:    name,
-		Name:     image,
-		Port:     port,
-		Registry: d.registry,
+		Id:         id,
+		Title:      name,
+		Name:       image,
+		Port:       port,
+		Management: d.runner.Management.Port,
+		Registry:   d.registry,
 	}
 
 	return result, nil
@@ -381,6 +414,7 @@ func (d *Docker) startContainer(image Image) (string, error) {
 
 	containerConfig := &container.Config{
 		Image: image.Name,
+		Env:   d.env,
 		ExposedPorts: nat.PortSet{
 			port: struct{}{},
 		},
@@ -397,6 +431,15 @@ func (d *Docker) startContainer(image Image) (string, error) {
 		},
 	}
 
+	management := nat.Port(fmt.Sprint(image.Management))
+
+	if image.Management > 0 {
+		// Every service runs in its own container with the same management port, so it is published
+		// on an ephemeral host port.
+		containerConfig.ExposedPorts[management] = struct{}{}
+		hostConfig.PortBindings[management] = []nat.PortBinding{{HostIP: "127.0.0.1"}}
+	}
+
 	cnt, err := d.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, image.Id)
 
 	if err != nil {
@@ -413,6 +456,21 @@ func (d *Docker) startContainer(image Image) (string, error) {
 
 	logger.Debug(fmt.Sprintf("Docker -> Container is running. ID: %s", id))
 
+	if image.Management > 0 {
+
+		info, err := d.client.ContainerInspect(ctx, id)
+
+		if err != nil {
+			return id, errors.Wrap(err, "Docker -> Unable to inspect container")
+		}
+
+		if info.NetworkSettings != nil {
+			for _, b := range info.NetworkSettings.Ports[management] {
+				logger.Info(fmt.Sprintf("Docker -> Management of [%s] published on %s:%s", image.Title, b.HostIP, b.HostPort))
+			}
+		}
+	}
+
 	return id, nil
 }
 
@@ -17,8 +17,12 @@ limitations under the License.
 package executor
 
 import (
+	"encoding/json"
 	"github.com/Trendyol/gaos/runner"
 	"github.com/pkg/errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 )
 
 const DOCKER = "docker"
@@ -31,7 +35,16 @@ type Executor interface {
 
 func NewExecutor(config Config) (Executor, error) {
 
-	gaos, err := runner.New(config.Scenario)
+	env, err := environment(config.Env)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gaos, err := runner.Discover(config.Scenario, func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
 
 	if err != nil {
 		return nil, err
@@ -39,7 +52,7 @@ func NewExecutor(config Config) (Executor, error) {
 
 	if config.Environment == DOCKER {
 
-		docker, err := NewDocker(*gaos)
+		docker, err := NewDocker(gaos)
 
 		if err != nil {
 			return nil, err
@@ -55,7 +68,7 @@ func NewExecutor(config Config) (Executor, error) {
 
 	} else if config.Environment == K8S {
 
-		k8s, err := NewKubernetes(*gaos)
+		k8s, err := NewKubernetes(gaos)
 
 		if err != nil {
 			return nil, err
@@ -73,3 +86,92 @@ func NewExecutor(config Config) (Executor, error) {
 
 	return nil, errors.New("Unexpected environment given. Available: 'docker', 'k8s'")
 }
+
+// scenarioFile is the root scenario file of images, read by `gaos run` in containers.
+const scenarioFile = "scenario.json"
+
+// scenarioDir holds every constituent file of a scenario split across files.
+const scenarioDir = "scenarios"
+
+// bundle returns the scenario files shipped into images, keyed by their path in the image. Files are kept as is,
+// so references are interpolated by the container at runtime. Split scenarios keep their layout under
+// `scenarios`, included by a generated root file.
+func bundle(g *runner.Runner) (map[string][]byte, error) {
+
+	files := g.Files()
+
+	if len(files) == 0 {
+
+		scenarioJson, err := json.Marshal(g)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string][]byte{scenarioFile: scenarioJson}, nil
+	}
+
+	if len(files) == 1 && g.Source() != nil {
+		return map[string][]byte{scenarioFile: g.Source()}, nil
+	}
+
+	root := filepath.Dir(files[0])
+
+	for _, f := range files {
+		for !strings.HasPrefix(f, root+string(filepath.Separator)) && root != filepath.Dir(root) {
+			root = filepath.Dir(root)
+		}
+	}
+
+	result := map[string][]byte{}
+	include := make([]string, 0, len(files))
+
+	for _, f := range files {
+
+		rel, err := filepath.Rel(root, f)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Scenario file can not packaged: %s", f)
+		}
+
+		content, err := ioutil.ReadFile(f)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Scenario file can not packaged: %s", f)
+		}
+
+		name := filepath.ToSlash(filepath.Join(scenarioDir, rel))
+
+		result[name] = content
+		include = append(include, name)
+	}
+
+	scenarioJson, err := json.Marshal(map[string][]string{"include": include})
+
+	if err != nil {
+		return nil, err
+	}
+
+	result[scenarioFile] = scenarioJson
+
+	return result, nil
+}
+
+// environment parses `KEY=VALUE` pairs given to containers.
+func environment(env []string) (map[string]string, error) {
+
+	result := map[string]string{}
+
+	for _, e := range env {
+
+		kv := strings.SplitN(e, "=", 2)
+
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return nil, errors.Errorf("Unexpected environment variable given: %s. Expected: KEY=VALUE", e)
+		}
+
+		result[kv[0]] = kv[1]
+	}
+
+	return result, nil
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package executor
 
 type Image struct {
-	Id       string `json:"id"`
-	Title    string `json:"title"`
-	Name     string `json:"name"`
-	Port     int32  `json:"port"`
-	Registry string `json:"registry"`
+	Id    string `json:"id"`
+	Title string `json:"title"`
+	Name  string `json:"name"`
+	Port  int32  `json:"port"`
+	// Management is the management port of the runner, 0 if it has none.
+	Management int32  `json:"management"`
+	Registry   string `json:"registry"`
 }
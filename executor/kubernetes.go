@@ -17,7 +17,6 @@ limitations under the License.
 package executor
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/Trendyol/gaos/logger"
@@ -32,11 +31,12 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 	"path/filepath"
+	"sort"
 	"strconv"
 )
 
 type Kubernetes struct {
-	runner    runner.Runner
+	runner    *runner.Runner
 	Scenario  string
 	Namespace string
 	Usage     string
@@ -44,13 +44,14 @@ type Kubernetes struct {
 	CPU       string
 	Memory    string
 	Secret    string
+	Env       map[string]string
 	client    *kubernetes.Clientset
 	docker    *Docker
 }
 
-func NewKubernetes(g runner.Runner) (*Kubernetes, error) {
+func NewKubernetes(g *runner.Runner) (*Kubernetes, error) {
 
-	scenarioJson, err := json.Marshal(g)
+	files, err := bundle(g)
 
 	if err != nil {
 		return nil, err
@@ -64,7 +65,7 @@ func NewKubernetes(g runner.Runner) (*Kubernetes, error) {
 	engine := &Kubernetes{
 		runner:   g,
 		docker:   docker,
-		Scenario: string(scenarioJson),
+		Scenario: string(files[scenarioFile]),
 	}
 
 	return engine, nil
@@ -88,6 +89,12 @@ func (k *Kubernetes) Initialize(c Config) error {
 	k.Secret = config["Secret"]
 	k.Usage = config["Config"]
 
+	k.Env, err = environment(c.Env)
+
+	if err != nil {
+		return errors.Wrap(err, "K8S -> Environment can not parsed")
+	}
+
 	k8sConfigStr := flag.String("kubeconfig", filepath.Join(homedir.HomeDir(), ".kube", "config"), k.Usage)
 
 	flag.Parse()
@@ -173,6 +180,26 @@ func (k *Kubernetes) createDeployment(image Image) error {
 		},
 	}
 
+	if image.Management > 0 {
+		deploymentSpec.Containers[0].Ports = append(deploymentSpec.Containers[0].Ports, apiv1.ContainerPort{
+			Name:          "management",
+			Protocol:      apiv1.ProtocolTCP,
+			ContainerPort: image.Management,
+		})
+	}
+
+	names := make([]string, 0, len(k.Env))
+
+	for name := range k.Env {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		deploymentSpec.Containers[0].Env = append(deploymentSpec.Containers[0].Env, apiv1.EnvVar{Name: name, Value: k.Env[name]})
+	}
+
 	if len(k.Secret) > 0 {
 		deploymentSpec.ImagePullSecrets = []apiv1.LocalObjectReference{
 			{
@@ -239,11 +266,20 @@ func (k *Kubernetes) createService(image Image) error {
 
 	servicePorts := []apiv1.ServicePort{
 		{
+			Name:       "http",
 			Port:       80,
 			TargetPort: intstr.FromInt(int(image.Port)),
 		},
 	}
 
+	if image.Management > 0 {
+		servicePorts = append(servicePorts, apiv1.ServicePort{
+			Name:       "management",
+			Port:       image.Management,
+			TargetPort: intstr.FromString("management"),
+		})
+	}
+
 	if err == nil && service != nil {
 
 		service.Spec.Ports = servicePorts
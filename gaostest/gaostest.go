@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gaostest runs Gaos services inside Go tests, similar to net/http/httptest.
+package gaostest
+
+import (
+	"context"
+	"fmt"
+	"github.com/Trendyol/gaos/runner"
+	"net"
+	"strings"
+	"testing"
+)
+
+type Server struct {
+	Runner    *runner.Runner
+	addresses map[string]string
+	cancel    context.CancelFunc
+	t         testing.TB
+}
+
+// NewServer starts every service of the given scenario document on a random port.
+// Servers are stopped when the test and all its subtests complete.
+func NewServer(t testing.TB, scenario string) *Server {
+	t.Helper()
+
+	g, err := runner.Load(strings.NewReader(scenario))
+
+	if err != nil {
+		t.Fatalf("gaostest: %s", err)
+	}
+
+	return NewServerFromRunner(t, g)
+}
+
+// NewServerFromRunner starts every service of g, and its management server unless disabled, on a random port.
+func NewServerFromRunner(t testing.TB, g *runner.Runner) *Server {
+	t.Helper()
+
+	for _, service := range g.Service {
+		service.Port = 0
+	}
+
+	g.Management.Port = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	addresses, err := g.Start(ctx)
+
+	if err != nil {
+		cancel()
+		t.Fatalf("gaostest: %s", err)
+	}
+
+	s := &Server{
+		Runner:    g,
+		addresses: addresses,
+		cancel:    cancel,
+		t:         t,
+	}
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Close stops every service and releases its port. It is called when the test completes.
+func (s *Server) Close() {
+	s.cancel()
+	_ = s.Runner.Shutdown()
+}
+
+// URL returns the base URL of a service, e.g. `http://127.0.0.1:41234`, or `https://` for services with TLS.
+func (s *Server) URL(service string) string {
+	s.t.Helper()
+
+	addr, ok := s.addresses[service]
+
+	if !ok {
+		s.t.Fatalf("gaostest: service [%s] is not running", service)
+	}
+
+	scheme := "http"
+
+	if service, ok := s.Runner.Service[service]; ok && service.TLS != nil {
+		scheme = "https"
+	}
+
+	return s.url(scheme, addr)
+}
+
+// ManagementURL returns the base URL of the management server, e.g. `http://127.0.0.1:41235`.
+func (s *Server) ManagementURL() string {
+	s.t.Helper()
+
+	addr := s.Runner.ManagementAddress()
+
+	if len(addr) == 0 {
+		s.t.Fatal("gaostest: management server is not running")
+	}
+
+	return s.url("http", addr)
+}
+
+func (s *Server) url(scheme, addr string) string {
+	s.t.Helper()
+
+	_, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		s.t.Fatalf("gaostest: %s", err)
+	}
+
+	return fmt.Sprintf("%s://127.0.0.1:%s", scheme, port)
+}
+
+// SetScenario switches the scenario handling next requests of a route.
+func (s *Server) SetScenario(service, path, scenario string) {
+	s.t.Helper()
+
+	if err := s.Runner.SetScenario(service, path, scenario); err != nil {
+		s.t.Fatalf("gaostest: %s", err)
+	}
+}
+
+// ActiveScenario returns the scenario handling next requests of a route.
+func (s *Server) ActiveScenario(service, path string) string {
+	s.t.Helper()
+
+	scenario, err := s.Runner.ActiveScenario(service, path)
+
+	if err != nil {
+		s.t.Fatalf("gaostest: %s", err)
+	}
+
+	return scenario
+}
+
+// Journal returns received requests matching q, oldest first.
+func (s *Server) Journal(q runner.Query) []runner.Entry {
+	return s.Runner.Journal.Find(q)
+}
+
+// ClearJournal forgets every received request.
+func (s *Server) ClearJournal() {
+	s.Runner.Journal.Clear()
+}
+
+// AssertCalled reports an error unless exactly n received requests match q.
+func (s *Server) AssertCalled(q runner.Query, n int) bool {
+	s.t.Helper()
+
+	if c := s.Runner.Journal.Count(q); c != n {
+		s.t.Errorf("gaostest: expected %d calls matching %+v, got %d", n, q, c)
+		return false
+	}
+
+	return true
+}
+
+// AssertNotCalled reports an error if any received request matches q.
+func (s *Server) AssertNotCalled(q runner.Query) bool {
+	s.t.Helper()
+
+	return s.AssertCalled(q, 0)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gaostest
+
+import (
+	"crypto/tls"
+	"github.com/Trendyol/gaos/runner"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const scenario = `{
+  "management": { "port": 9090 },
+  "service": {
+    "search": {
+      "port": 9082,
+      "path": {
+        "/api/{id}": { "scenario": "ok", "method": "GET" }
+      }
+    },
+    "secure": {
+      "port": 9443,
+      "tls": { "auto": true },
+      "path": {
+        "/api/{id}": { "scenario": "ok", "method": "GET" }
+      }
+    }
+  },
+  "scenario": {
+    "ok": {
+      "accept": { "status": 200, "result": { "type": "static", "content": { "name": "gaos" } } }
+    },
+    "error": {
+      "accept": { "status": 500, "result": { "type": "static", "content": { "error": "boom" } } }
+    }
+  }
+}`
+
+func TestServer(t *testing.T) {
+
+	s := NewServer(t, scenario)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+	tests := []struct {
+		service string
+		scheme  string
+	}{
+		{service: "search", scheme: "http://"},
+		{service: "secure", scheme: "https://"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+
+			url := s.URL(tt.service)
+
+			if !strings.HasPrefix(url, tt.scheme) {
+				t.Fatalf("URL() = %s, want %s scheme", url, tt.scheme)
+			}
+
+			res, err := client.Get(url + "/api/42")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, _ := ioutil.ReadAll(res.Body)
+			_ = res.Body.Close()
+
+			if res.StatusCode != http.StatusOK || string(body) != `{"name":"gaos"}` {
+				t.Errorf("GET = %d %s, want 200 {\"name\":\"gaos\"}", res.StatusCode, body)
+			}
+		})
+	}
+
+	s.SetScenario("search", "/api/{id}", "error")
+
+	if got := s.ActiveScenario("search", "/api/{id}"); got != "error" {
+		t.Errorf("ActiveScenario() = %s, want error", got)
+	}
+
+	s.AssertCalled(runner.Query{Service: "search", Path: "/api/42"}, 1)
+}
+
+func TestServerManagementURL(t *testing.T) {
+
+	s := NewServer(t, scenario)
+
+	url := s.ManagementURL()
+
+	if strings.HasSuffix(url, ":9090") {
+		t.Fatalf("ManagementURL() = %s, want a random port", url)
+	}
+
+	res, err := http.Get(url + "/metrics")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("GET /metrics = %d, want 200", res.StatusCode)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+
+	s := NewServer(t, scenario)
+
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(s.URL("search"), "http://"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Close()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:"+port)
+
+	if err != nil {
+		t.Fatalf("port %s is not released after Close: %s", port, err)
+	}
+
+	_ = ln.Close()
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func (g *Graph) dot(w io.Writer) error {
+
+	var b bytes.Buffer
+
+	b.WriteString("digraph gaos {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=11];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, s := range g.Services {
+
+		fmt.Fprintf(&b, "  subgraph %s {\n", strconv.Quote("cluster_"+s.Name))
+		fmt.Fprintf(&b, "    label=%s;\n", strconv.Quote(fmt.Sprintf("%s :%d", s.Name, s.Port)))
+		b.WriteString("    style=rounded;\n")
+
+		for _, r := range s.Routes {
+			fmt.Fprintf(&b, "    %s [shape=box, label=%s];\n", routeId(s, r), strconv.Quote(r.Method+" "+r.Path))
+		}
+
+		b.WriteString("  }\n")
+	}
+
+	for _, s := range g.Scenarios {
+
+		label := strings.Join(append([]string{s.Name}, s.Conditions...), "\n")
+		attributes := "shape=ellipse"
+
+		switch {
+		case s.Missing:
+			label += "\n(missing)"
+			attributes += ", style=dashed, color=\"#d33\", fontcolor=\"#d33\""
+		case s.Unreachable:
+			label += "\n(unreachable)"
+			attributes += ", style=filled, fillcolor=\"#eeeeee\", fontcolor=\"#888888\""
+		}
+
+		fmt.Fprintf(&b, "  %s [%s, label=%s];\n", scenarioId(s.Name), attributes, strconv.Quote(label))
+	}
+
+	for _, s := range g.Services {
+		for _, r := range s.Routes {
+			fmt.Fprintf(&b, "  %s -> %s;\n", routeId(s, r), scenarioId(r.Scenario))
+		}
+	}
+
+	missing := map[string]bool{}
+
+	for _, name := range g.Missing() {
+		missing[name] = true
+	}
+
+	for _, e := range g.Edges {
+
+		attributes := fmt.Sprintf("label=%s, color=\"%s\"", strconv.Quote(e.Action), actionColor(e.Action))
+
+		if missing[e.To] {
+			attributes += ", style=dashed"
+		}
+
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", scenarioId(e.From), scenarioId(e.To), attributes)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := w.Write(b.Bytes())
+
+	return err
+}
+
+func routeId(s Service, r Route) string {
+	return strconv.Quote(fmt.Sprintf("route:%s:%s %s", s.Name, r.Method, r.Path))
+}
+
+func scenarioId(name string) string {
+	return strconv.Quote("scenario:" + name)
+}
+
+func actionColor(action string) string {
+
+	if action == "ignore" {
+		return "#d33"
+	}
+
+	return "#2a2"
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"fmt"
+	"github.com/Trendyol/gaos/runner"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+)
+
+const (
+	FormatDot     = "dot"
+	FormatMermaid = "mermaid"
+	FormatSvg     = "svg"
+)
+
+// Graph is the state machine of a runner: routes enter scenarios, and `direct` actions move between them.
+type Graph struct {
+	Services  []Service
+	Scenarios []Scenario
+	Edges     []Edge
+}
+
+type Service struct {
+	Name   string
+	Port   int32
+	Routes []Route
+}
+
+type Route struct {
+	Method   string
+	Path     string
+	Scenario string
+}
+
+type Scenario struct {
+	Name       string
+	Conditions []string
+	// Missing scenarios are referenced but not defined.
+	Missing bool
+	// Unreachable scenarios are neither the entry of a route or an experiment phase nor a `direct` target of a reachable one.
+	Unreachable bool
+	// Depth is the smallest count of transitions from a route or an experiment phase, -1 if unreachable.
+	Depth int
+}
+
+type Edge struct {
+	From   string
+	To     string
+	Action string
+}
+
+// Build returns the graph of the effective scenarios of g, after templates and inheritance are resolved.
+func Build(g *runner.Runner) (*Graph, error) {
+
+	err := g.Expand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &Graph{}
+	scenarios := map[string]*Scenario{}
+
+	for _, name := range sortedKeys(g.Scenario) {
+		scenarios[name] = &Scenario{Name: name, Conditions: conditions(g.Scenario[name]), Depth: -1}
+	}
+
+	reference := func(name string) {
+		if _, ok := scenarios[name]; !ok {
+			scenarios[name] = &Scenario{Name: name, Missing: true, Depth: -1}
+		}
+	}
+
+	var entries []string
+
+	for _, name := range sortedKeys(g.Service) {
+
+		s := g.Service[name]
+
+		if s == nil {
+			return nil, errors.Errorf("Service [%s] is empty", name)
+		}
+
+		service := Service{Name: name, Port: s.Port}
+
+		for _, path := range sortedKeys(s.Path) {
+
+			p := s.Path[path]
+
+			service.Routes = append(service.Routes, Route{Method: p.Method, Path: path, Scenario: p.Scenario})
+
+			reference(p.Scenario)
+			entries = append(entries, p.Scenario)
+		}
+
+		if s.Fallback != nil && len(s.Fallback.Scenario) > 0 {
+
+			service.Routes = append(service.Routes, Route{Method: runner.FallbackRoute, Path: "(fallback)", Scenario: s.Fallback.Scenario})
+
+			reference(s.Fallback.Scenario)
+			entries = append(entries, s.Fallback.Scenario)
+		}
+
+		graph.Services = append(graph.Services, service)
+	}
+
+	// Phases of experiments switch routes to their scenarios at runtime, so they are entries as well.
+	for _, e := range g.Experiment {
+
+		if e == nil {
+			continue
+		}
+
+		for _, phase := range e.Phases {
+
+			if phase == nil {
+				continue
+			}
+
+			for _, routes := range phase.Routes {
+				for _, scenario := range routes {
+
+					reference(scenario)
+					entries = append(entries, scenario)
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(g.Scenario) {
+
+		s := g.Scenario[name]
+
+		for _, a := range []struct {
+			name   string
+			direct string
+		}{{runner.ActionAccept, s.Accept.Direct}, {runner.ActionIgnore, s.Ignore.Direct}} {
+
+			if len(a.direct) == 0 {
+				continue
+			}
+
+			reference(a.direct)
+
+			graph.Edges = append(graph.Edges, Edge{From: name, To: a.direct, Action: a.name})
+		}
+	}
+
+	queue := entries
+
+	for _, e := range entries {
+		scenarios[e].Depth = 0
+	}
+
+	for len(queue) > 0 {
+
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, e := range graph.Edges {
+			if e.From == current && scenarios[e.To].Depth < 0 {
+				scenarios[e.To].Depth = scenarios[current].Depth + 1
+				queue = append(queue, e.To)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(scenarios) {
+
+		s := scenarios[name]
+		s.Unreachable = s.Depth < 0
+
+		graph.Scenarios = append(graph.Scenarios, *s)
+	}
+
+	return graph, nil
+}
+
+// Missing returns the names of referenced but undefined scenarios.
+func (g *Graph) Missing() []string {
+
+	var result []string
+
+	for _, s := range g.Scenarios {
+		if s.Missing {
+			result = append(result, s.Name)
+		}
+	}
+
+	return result
+}
+
+// Unreachable returns the names of defined scenarios no request can reach.
+func (g *Graph) Unreachable() []string {
+
+	var result []string
+
+	for _, s := range g.Scenarios {
+		if s.Unreachable && !s.Missing {
+			result = append(result, s.Name)
+		}
+	}
+
+	return result
+}
+
+// Write renders the graph in the given format.
+func (g *Graph) Write(w io.Writer, format string) error {
+
+	switch format {
+	case FormatDot:
+		return g.dot(w)
+	case FormatMermaid:
+		return g.mermaid(w)
+	case FormatSvg:
+		return g.svg(w)
+	}
+
+	return errors.Errorf("Unexpected graph format given: %s. Available: '%s', '%s', '%s'", format, FormatDot, FormatMermaid, FormatSvg)
+}
+
+func conditions(s *runner.Scenario) []string {
+
+	var result []string
+
+	if len(s.Subject) > 0 {
+		result = append(result, fmt.Sprintf("subject %s", s.Subject))
+	}
+
+	if len(s.Start) > 0 || len(s.End) > 0 {
+		result = append(result, fmt.Sprintf("span %s - %s", s.Start, s.End))
+	}
+
+	if len(s.Duration) > 0 {
+		result = append(result, fmt.Sprintf("duration %s", s.Duration))
+	}
+
+	if len(s.Latency) > 0 {
+		result = append(result, fmt.Sprintf("latency %s", s.Latency))
+	}
+
+	if s.Limit > 0 {
+		result = append(result, fmt.Sprintf("limit %d", s.Limit))
+	}
+
+	if s.Rate > 0 {
+		result = append(result, fmt.Sprintf("rate %d", s.Rate))
+	}
+
+	return result
+}
+
+func (s Scenario) status() string {
+
+	if s.Missing {
+		return "missing"
+	}
+
+	if s.Unreachable {
+		return "unreachable"
+	}
+
+	return ""
+}
+
+// sortedKeys returns the keys of m in order, so graphs render the same on every run.
+func sortedKeys[V any](m map[string]V) []string {
+
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
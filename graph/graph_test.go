@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Trendyol/gaos/runner"
+)
+
+func TestBuild(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		document    string
+		depths      map[string]int
+		missing     []string
+		unreachable []string
+		err         bool
+	}{
+		{
+			name: "direct",
+			document: `{
+				"service": {"search": {"port": 8080, "path": {"/api": {"method": "GET", "scenario": "latency"}}}},
+				"scenario": {
+					"latency": {"ignore": {"direct": "error"}},
+					"error": {"accept": {"direct": "healthy"}},
+					"healthy": {},
+					"orphan": {"accept": {"direct": "ghost"}}
+				}
+			}`,
+			depths:      map[string]int{"latency": 0, "error": 1, "healthy": 2, "orphan": -1, "ghost": -1},
+			missing:     []string{"ghost"},
+			unreachable: []string{"orphan"},
+		},
+		{
+			name: "experiment phase",
+			document: `{
+				"service": {"search": {"port": 8080, "path": {"/api": {"method": "GET", "scenario": "healthy"}}}},
+				"scenario": {
+					"healthy": {},
+					"outage": {"ignore": {"direct": "recovering"}},
+					"recovering": {}
+				},
+				"experiment": {
+					"black-friday": {"phases": [{"name": "outage", "duration": "1m", "routes": {"search": {"/api": "outage"}}}, {"name": "typo", "duration": "1m", "routes": {"search": {"/api": "outgae"}}}]}
+				}
+			}`,
+			depths:  map[string]int{"healthy": 0, "outage": 0, "recovering": 1, "outgae": 0},
+			missing: []string{"outgae"},
+		},
+		{
+			name:     "null service",
+			document: `{"service": {"search": null}, "scenario": {"healthy": {}}}`,
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			g, err := runner.Load(strings.NewReader(tt.document))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gr, err := Build(g)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("Build succeeded, want error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			depths := map[string]int{}
+
+			for _, s := range gr.Scenarios {
+				depths[s.Name] = s.Depth
+			}
+
+			if !reflect.DeepEqual(depths, tt.depths) {
+				t.Errorf("depths = %v, want %v", depths, tt.depths)
+			}
+
+			if got := gr.Missing(); !reflect.DeepEqual(got, tt.missing) {
+				t.Errorf("Missing() = %v, want %v", got, tt.missing)
+			}
+
+			if got := gr.Unreachable(); !reflect.DeepEqual(got, tt.unreachable) {
+				t.Errorf("Unreachable() = %v, want %v", got, tt.unreachable)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (g *Graph) mermaid(w io.Writer) error {
+
+	var b bytes.Buffer
+
+	ids := map[string]string{}
+
+	for i, s := range g.Scenarios {
+		ids[s.Name] = fmt.Sprintf("s%d", i)
+	}
+
+	b.WriteString("flowchart LR\n")
+
+	route := 0
+
+	for i, s := range g.Services {
+
+		fmt.Fprintf(&b, "  subgraph service%d [\"%s :%d\"]\n", i, mermaidText(s.Name), s.Port)
+
+		for _, r := range s.Routes {
+			fmt.Fprintf(&b, "    r%d[\"%s %s\"]\n", route, mermaidText(r.Method), mermaidText(r.Path))
+			route++
+		}
+
+		b.WriteString("  end\n")
+	}
+
+	for _, s := range g.Scenarios {
+
+		lines := []string{mermaidText(s.Name)}
+
+		for _, c := range s.Conditions {
+			lines = append(lines, mermaidText(c))
+		}
+
+		if status := s.status(); len(status) > 0 {
+			lines = append(lines, "("+status+")")
+		}
+
+		fmt.Fprintf(&b, "  %s([\"%s\"])\n", ids[s.Name], strings.Join(lines, "<br/>"))
+	}
+
+	route = 0
+
+	for _, s := range g.Services {
+		for _, r := range s.Routes {
+			fmt.Fprintf(&b, "  r%d --> %s\n", route, ids[r.Scenario])
+			route++
+		}
+	}
+
+	for i, e := range g.Edges {
+
+		arrow := "-->"
+
+		if e.Action == "ignore" {
+			arrow = "-.->"
+		}
+
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.From], arrow, e.Action, ids[e.To])
+		fmt.Fprintf(&b, "  linkStyle %d stroke:%s\n", route+i, actionColor(e.Action))
+	}
+
+	b.WriteString("  classDef missing stroke:#d33,stroke-dasharray:4,color:#d33\n")
+	b.WriteString("  classDef unreachable fill:#eee,color:#888\n")
+
+	for _, s := range g.Scenarios {
+		if status := s.status(); len(status) > 0 {
+			fmt.Fprintf(&b, "  class %s %s\n", ids[s.Name], status)
+		}
+	}
+
+	_, err := w.Write(b.Bytes())
+
+	return err
+}
+
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
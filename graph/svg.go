@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const (
+	margin     = 20
+	nodeWidth  = 220
+	columnGap  = 90
+	rowGap     = 18
+	lineHeight = 16
+	padding    = 8
+)
+
+// box is a laid out node of the svg.
+type box struct {
+	x, y, w, h int
+	lines      []string
+	status     string
+	route      bool
+}
+
+func (b box) left() (int, int)  { return b.x, b.y + b.h/2 }
+func (b box) right() (int, int) { return b.x + b.w, b.y + b.h/2 }
+
+// svg lays routes out in the first column and scenarios in the columns of their depth, unreachable ones last.
+func (g *Graph) svg(w io.Writer) error {
+
+	columns := map[int]int{}
+	routes := map[string]box{}
+	scenarios := map[string]box{}
+
+	var frames []box
+
+	y := margin
+
+	for _, s := range g.Services {
+
+		top := y
+		y += lineHeight + padding
+
+		for _, r := range s.Routes {
+			b := box{x: margin + padding, y: y, w: nodeWidth - 2*padding, h: lineHeight + 2*padding, lines: []string{r.Method + " " + r.Path}, route: true}
+			routes[s.Name+" "+r.Method+" "+r.Path] = b
+			y += b.h + padding
+		}
+
+		frames = append(frames, box{x: margin, y: top, w: nodeWidth, h: y - top, lines: []string{fmt.Sprintf("%s :%d", s.Name, s.Port)}})
+
+		y += rowGap
+	}
+
+	columns[0] = y
+
+	last := 1
+
+	for _, s := range g.Scenarios {
+		if s.Depth+1 > last {
+			last = s.Depth + 1
+		}
+	}
+
+	for _, s := range g.Scenarios {
+
+		column := s.Depth + 1
+
+		if s.Depth < 0 {
+			column = last + 1
+		}
+
+		lines := append([]string{s.Name}, s.Conditions...)
+
+		if status := s.status(); len(status) > 0 {
+			lines = append(lines, "("+status+")")
+		}
+
+		if _, ok := columns[column]; !ok {
+			columns[column] = margin
+		}
+
+		b := box{
+			x:      margin + column*(nodeWidth+columnGap),
+			y:      columns[column],
+			w:      nodeWidth,
+			h:      len(lines)*lineHeight + 2*padding,
+			lines:  lines,
+			status: s.status(),
+		}
+
+		scenarios[s.Name] = b
+		columns[column] += b.h + rowGap
+	}
+
+	width, height := 0, 0
+
+	for c, y := range columns {
+		if x := margin + (c+1)*(nodeWidth+columnGap); x > width {
+			width = x
+		}
+
+		if y > height {
+			height = y
+		}
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"Helvetica, Arial, sans-serif\" font-size=\"12\">\n", width, height+margin)
+	b.WriteString("  <defs>\n")
+
+	for _, m := range []struct{ id, color string }{{"route", "#888"}, {"accept", actionColor("accept")}, {"ignore", actionColor("ignore")}} {
+		fmt.Fprintf(&b, "    <marker id=\"arrow-%s\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"7\" markerHeight=\"7\" orient=\"auto\"><path d=\"M0,0 L10,5 L0,10 z\" fill=\"%s\"/></marker>\n", m.id, m.color)
+	}
+
+	b.WriteString("  </defs>\n")
+	b.WriteString("  <rect width=\"100%\" height=\"100%\" fill=\"#fff\"/>\n")
+
+	for _, f := range frames {
+		fmt.Fprintf(&b, "  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"8\" fill=\"#f6f8fa\" stroke=\"#bbb\"/>\n", f.x, f.y, f.w, f.h)
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\" font-weight=\"bold\">%s</text>\n", f.x+padding, f.y+lineHeight, escape(f.lines[0]))
+	}
+
+	for _, s := range g.Services {
+		for _, r := range s.Routes {
+			from := routes[s.Name+" "+r.Method+" "+r.Path]
+			edge(&b, from, scenarios[r.Scenario], "route", "")
+		}
+	}
+
+	for _, e := range g.Edges {
+		edge(&b, scenarios[e.From], scenarios[e.To], e.Action, e.Action)
+	}
+
+	for _, s := range g.Services {
+		for _, r := range s.Routes {
+			node(&b, routes[s.Name+" "+r.Method+" "+r.Path])
+		}
+	}
+
+	for _, s := range g.Scenarios {
+		node(&b, scenarios[s.Name])
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := w.Write(b.Bytes())
+
+	return err
+}
+
+func node(b *bytes.Buffer, n box) {
+
+	fill, stroke, text, dash := "#fff", "#555", "#222", ""
+
+	switch {
+	case n.route:
+		stroke = "#888"
+	case n.status == "missing":
+		stroke, text, dash = actionColor("ignore"), actionColor("ignore"), " stroke-dasharray=\"4\""
+	case n.status == "unreachable":
+		fill, stroke, text = "#eee", "#aaa", "#888"
+	}
+
+	radius := 14
+
+	if n.route {
+		radius = 3
+	}
+
+	fmt.Fprintf(b, "  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"%d\" fill=\"%s\" stroke=\"%s\"%s/>\n", n.x, n.y, n.w, n.h, radius, fill, stroke, dash)
+
+	for i, l := range n.lines {
+
+		weight := ""
+
+		if i == 0 && !n.route {
+			weight = " font-weight=\"bold\""
+		}
+
+		fmt.Fprintf(b, "  <text x=\"%d\" y=\"%d\" fill=\"%s\"%s>%s</text>\n", n.x+padding, n.y+padding+(i+1)*lineHeight-4, text, weight, escape(l))
+	}
+}
+
+// edge draws a straight arrow to later columns, and a curve for transitions back, within a column or to itself.
+func edge(b *bytes.Buffer, from, to box, kind, label string) {
+
+	color := "#888"
+
+	if kind != "route" {
+		color = actionColor(kind)
+	}
+
+	dash := ""
+
+	if kind == "ignore" {
+		dash = " stroke-dasharray=\"5,3\""
+	}
+
+	x1, y1 := from.right()
+	x2, y2 := to.left()
+
+	var d string
+	var lx, ly int
+
+	switch {
+	case from.x == to.x && from.y == to.y:
+		d = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1-6, x1+60, y1-40, x1+60, y1+40, x1, y1+6)
+		lx, ly = x1+50, y1
+	case x2 > x1:
+		d = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, x1+columnGap/2, y1, x2-columnGap/2, y2, x2, y2)
+		lx, ly = (x1+x2)/2, (y1+y2)/2-4
+	default:
+		x2, y2 = to.right()
+		bend := 60 + (x1-x2)/4
+		d = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, x1+bend, y1, x2+bend, y2, x2, y2)
+		lx, ly = (x1+x2)/2+bend*3/4, (y1+y2)/2
+	}
+
+	fmt.Fprintf(b, "  <path d=\"%s\" fill=\"none\" stroke=\"%s\"%s marker-end=\"url(#arrow-%s)\"/>\n", d, color, dash, kind)
+
+	if len(label) > 0 {
+		fmt.Fprintf(b, "  <text x=\"%d\" y=\"%d\" fill=\"%s\" font-size=\"10\" text-anchor=\"middle\">%s</text>\n", lx, ly, color, escape(label))
+	}
+}
+
+func escape(s string) string {
+
+	var b bytes.Buffer
+
+	_ = xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
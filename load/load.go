@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package load generates HTTP traffic at a constant or ramping rate, to drive services depending on Gaos mocks.
+package load
+
+import (
+	"context"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ProfileConstant = "constant"
+	ProfileRamp     = "ramp"
+)
+
+const (
+	DefaultTimeout     = 5 * time.Second
+	DefaultConcurrency = 1000
+)
+
+// Config describes the requests and their rate. The `constant` profile sends Rate requests per second,
+// and `ramp` changes the rate linearly from RampFrom to Rate over the Duration.
+type Config struct {
+	URL         string
+	Method      string
+	Header      map[string]string
+	Body        []byte
+	Profile     string
+	Rate        float64
+	RampFrom    float64
+	Duration    time.Duration
+	Timeout     time.Duration
+	Concurrency int
+}
+
+// Event runs at an offset of the run and starts a new phase of the report, e.g. a scenario switch.
+type Event struct {
+	At   time.Duration
+	Name string
+	Fire func() error
+}
+
+type result struct {
+	phase   int
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// Run sends requests until the duration elapses or ctx is done, firing events on the way, and waits for
+// requests in flight before reporting.
+func Run(ctx context.Context, c Config, events ...Event) (*Report, error) {
+
+	err := c.validate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	client := &fasthttp.Client{MaxConnsPerHost: c.Concurrency}
+
+	report := newReport(c)
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	phase := 0
+	slots := make(chan bool, c.Concurrency)
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, c.Duration)
+	defer cancel()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At < events[j].At
+	})
+
+	fired := make(chan bool)
+
+	go func() {
+		defer close(fired)
+
+		for _, e := range events {
+
+			if e.At >= c.Duration {
+				logger.Warn(fmt.Sprintf("Event [%s] at %s is after the end of the load", e.Name, e.At))
+				continue
+			}
+
+			timer := time.NewTimer(time.Until(start.Add(e.At)))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			err := e.Fire()
+
+			if err != nil {
+				logger.Error(errors.Wrapf(err, "Event [%s] can not fired", e.Name))
+			}
+
+			mutex.Lock()
+			phase = report.begin(e, time.Since(start), err)
+			mutex.Unlock()
+		}
+	}()
+
+	for i := 0; ; i++ {
+
+		at := c.offset(i)
+
+		if at >= c.Duration {
+			break
+		}
+
+		timer := time.NewTimer(time.Until(start.Add(at)))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		slots <- true
+		wg.Add(1)
+
+		mutex.Lock()
+		p := phase
+		mutex.Unlock()
+
+		go func() {
+			defer wg.Done()
+
+			r := send(client, c, p)
+
+			<-slots
+
+			mutex.Lock()
+			report.record(r)
+			mutex.Unlock()
+		}()
+	}
+
+	<-ctx.Done()
+	<-fired
+
+	wg.Wait()
+	report.finish(time.Since(start))
+
+	return report, nil
+}
+
+func (c *Config) validate() error {
+
+	if len(c.URL) == 0 {
+		return errors.New("Load target URL must be given")
+	}
+
+	if c.Rate <= 0 {
+		return errors.Errorf("Load rate must be positive. Value: %g", c.Rate)
+	}
+
+	if c.Duration <= 0 {
+		return errors.Errorf("Load duration must be positive. Value: %s", c.Duration)
+	}
+
+	if len(c.Profile) == 0 {
+		c.Profile = ProfileConstant
+	}
+
+	if c.Profile != ProfileConstant && c.Profile != ProfileRamp {
+		return errors.Errorf("Unexpected load profile given: %s", c.Profile)
+	}
+
+	if c.Profile == ProfileRamp && c.RampFrom < 0 {
+		return errors.Errorf("Load ramp start rate can not be negative. Value: %g", c.RampFrom)
+	}
+
+	if len(c.Method) == 0 {
+		c.Method = fasthttp.MethodGet
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultConcurrency
+	}
+
+	return nil
+}
+
+// offset returns when the i-th request is sent: the time the integral of the rate reaches i.
+func (c Config) offset(i int) time.Duration {
+
+	a := 0.0
+	b := c.Rate
+
+	if c.Profile == ProfileRamp {
+		a = (c.Rate - c.RampFrom) / (2 * c.Duration.Seconds())
+		b = c.RampFrom
+	}
+
+	if a == 0 {
+		return time.Duration(float64(i) / b * float64(time.Second))
+	}
+
+	d := b*b + 4*a*float64(i)
+
+	if d < 0 {
+		return c.Duration
+	}
+
+	return time.Duration((-b + math.Sqrt(d)) / (2 * a) * float64(time.Second))
+}
+
+func send(client *fasthttp.Client, c Config, phase int) result {
+
+	req := fasthttp.AcquireRequest()
+	res := fasthttp.AcquireResponse()
+
+	defer fasthttp.ReleaseResponse(res)
+	defer fasthttp.ReleaseRequest(req)
+
+	req.SetRequestURI(c.URL)
+	req.Header.SetMethod(c.Method)
+
+	for k, v := range c.Header {
+		req.Header.Set(k, v)
+	}
+
+	if len(c.Body) > 0 {
+		req.SetBody(c.Body)
+	}
+
+	start := time.Now()
+
+	err := client.DoTimeout(req, res, c.Timeout)
+
+	return result{phase: phase, latency: time.Since(start), status: res.StatusCode(), err: err}
+}
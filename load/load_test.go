@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigOffset(t *testing.T) {
+
+	constant := Config{Profile: ProfileConstant, Rate: 10, Duration: 10 * time.Second}
+	ramp := Config{Profile: ProfileRamp, RampFrom: 0, Rate: 10, Duration: 10 * time.Second}
+	down := Config{Profile: ProfileRamp, RampFrom: 10, Rate: 0.0001, Duration: 10 * time.Second}
+	flat := Config{Profile: ProfileRamp, RampFrom: 5, Rate: 5, Duration: 10 * time.Second}
+
+	tests := []struct {
+		name   string
+		config Config
+		i      int
+		want   time.Duration
+	}{
+		{name: "constant first", config: constant, i: 0, want: 0},
+		{name: "constant", config: constant, i: 25, want: 2500 * time.Millisecond},
+		{name: "ramp first", config: ramp, i: 0, want: 0},
+		// The rate grows by 1/s every second, so 0.5*t^2 requests are sent by t.
+		{name: "ramp quarter", config: ramp, i: 2, want: 2 * time.Second},
+		{name: "ramp end", config: ramp, i: 50, want: 10 * time.Second},
+		{name: "ramp down", config: down, i: 18, want: 2 * time.Second},
+		{name: "ramp down past the end", config: down, i: 1000, want: 10 * time.Second},
+		{name: "flat ramp", config: flat, i: 10, want: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := tt.config.offset(tt.i)
+
+			if diff := got - tt.want; diff > time.Millisecond || diff < -time.Millisecond {
+				t.Errorf("offset(%d) = %s, want %s", tt.i, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsPercentile(t *testing.T) {
+
+	tests := []struct {
+		latencies []int
+		p         int
+		want      int
+	}{
+		{latencies: []int{7}, p: 50, want: 7},
+		{latencies: []int{7}, p: 99, want: 7},
+		{latencies: []int{1, 2, 3, 4}, p: 50, want: 2},
+		{latencies: []int{1, 2, 3, 4}, p: 51, want: 3},
+		{latencies: []int{1, 2, 3, 4}, p: 100, want: 4},
+		{latencies: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 90, want: 9},
+		{latencies: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 95, want: 10},
+		{latencies: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+
+		s := Stats{}
+
+		for _, l := range tt.latencies {
+			s.latencies = append(s.latencies, time.Duration(l)*time.Millisecond)
+		}
+
+		if got := s.percentile(tt.p); got != time.Duration(tt.want)*time.Millisecond {
+			t.Errorf("percentile(%d) of %v = %s, want %dms", tt.p, tt.latencies, got, tt.want)
+		}
+	}
+}
+
+func TestReportBegin(t *testing.T) {
+
+	r := newReport(Config{Profile: ProfileConstant})
+
+	r.record(result{phase: 0, status: 200, latency: time.Millisecond})
+
+	at := r.begin(Event{At: time.Second, Name: "search:/api:error"}, time.Second, nil)
+
+	r.record(result{phase: at, status: 500, latency: time.Millisecond})
+	r.record(result{phase: at, status: 500, latency: 3 * time.Millisecond})
+
+	r.finish(2 * time.Second)
+
+	if len(r.Phases) != 2 {
+		t.Fatalf("phases = %+v, want start and search:/api:error", r.Phases)
+	}
+
+	if p := r.Phases[1]; p.Requests != 2 || p.Status[500] != 2 || p.Latency.Max != 3*time.Millisecond {
+		t.Errorf("phase = %+v, want 2 requests with status 500", p)
+	}
+
+	if r.Total.Requests != 3 {
+		t.Errorf("total requests = %d, want 3", r.Total.Requests)
+	}
+}
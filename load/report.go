@@ -0,0 +1,325 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+type Report struct {
+	URL      string        `json:"url"`
+	Method   string        `json:"method"`
+	Profile  string        `json:"profile"`
+	Rate     float64       `json:"rate"`
+	RampFrom float64       `json:"rampFrom,omitempty"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Total    Stats         `json:"total"`
+	Phases   []Phase       `json:"phases"`
+}
+
+// Phase is the part of a run between two events, the first one starting with the run.
+type Phase struct {
+	Name  string        `json:"name"`
+	At    time.Duration `json:"at"`
+	Error string        `json:"error,omitempty"`
+	Stats
+}
+
+type Stats struct {
+	Requests  int            `json:"requests"`
+	Timeouts  int            `json:"timeouts"`
+	Status    map[int]int    `json:"status"`
+	Errors    map[string]int `json:"errors"`
+	Latency   Latency        `json:"latency"`
+	latencies []time.Duration
+}
+
+// Latency of requests which got a response. Timeouts and connection errors are not included.
+type Latency struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+func newReport(c Config) *Report {
+
+	r := &Report{
+		URL:     c.URL,
+		Method:  c.Method,
+		Profile: c.Profile,
+		Rate:    c.Rate,
+		Total:   newStats(),
+		Phases:  []Phase{{Name: "start", Stats: newStats()}},
+	}
+
+	if c.Profile == ProfileRamp {
+		r.RampFrom = c.RampFrom
+	}
+
+	return r
+}
+
+func newStats() Stats {
+	return Stats{Status: map[int]int{}, Errors: map[string]int{}}
+}
+
+// begin starts the phase of an event and returns its index. Events at 0 replace the initial phase.
+func (r *Report) begin(e Event, at time.Duration, err error) int {
+
+	p := Phase{Name: e.Name, At: at, Stats: newStats()}
+
+	if err != nil {
+		p.Error = err.Error()
+	}
+
+	last := len(r.Phases) - 1
+
+	if e.At == 0 && last == 0 && r.Phases[last].Requests == 0 {
+		p.At = 0
+		r.Phases[last] = p
+
+		return last
+	}
+
+	r.Phases = append(r.Phases, p)
+
+	return len(r.Phases) - 1
+}
+
+func (r *Report) record(res result) {
+	r.Total.add(res)
+	r.Phases[res.phase].add(res)
+}
+
+func (r *Report) finish(elapsed time.Duration) {
+
+	r.Elapsed = elapsed
+	r.Total.summarize()
+
+	for i := range r.Phases {
+		r.Phases[i].summarize()
+	}
+}
+
+func (s *Stats) add(res result) {
+
+	s.Requests++
+
+	if res.err == fasthttp.ErrTimeout || res.err == fasthttp.ErrDialTimeout {
+		s.Timeouts++
+		return
+	}
+
+	if res.err != nil {
+		s.Errors[res.err.Error()]++
+		return
+	}
+
+	s.Status[res.status]++
+	s.latencies = append(s.latencies, res.latency)
+}
+
+func (s *Stats) summarize() {
+
+	if len(s.latencies) == 0 {
+		return
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool {
+		return s.latencies[i] < s.latencies[j]
+	})
+
+	var sum time.Duration
+
+	for _, l := range s.latencies {
+		sum += l
+	}
+
+	s.Latency = Latency{
+		Min:  s.latencies[0],
+		Mean: sum / time.Duration(len(s.latencies)),
+		P50:  s.percentile(50),
+		P90:  s.percentile(90),
+		P95:  s.percentile(95),
+		P99:  s.percentile(99),
+		Max:  s.latencies[len(s.latencies)-1],
+	}
+}
+
+// percentile uses the nearest rank of sorted latencies.
+func (s *Stats) percentile(p int) time.Duration {
+
+	i := (p*len(s.latencies)+99)/100 - 1
+
+	if i < 0 {
+		i = 0
+	}
+
+	return s.latencies[i]
+}
+
+// Write writes the report as a text summary or json.
+func (r *Report) Write(w io.Writer, format string) error {
+
+	switch format {
+	case FormatJson:
+
+		body, err := json.MarshalIndent(r, "", "  ")
+
+		if err != nil {
+			return errors.Wrap(err, "Load report marshalling error")
+		}
+
+		_, err = fmt.Fprintln(w, string(body))
+
+		return err
+
+	case FormatText, "":
+		return r.text(w)
+	}
+
+	return errors.Errorf("Unexpected report format given: %s", format)
+}
+
+func (r *Report) text(w io.Writer) error {
+
+	rate := fmt.Sprintf("%g/s", r.Rate)
+
+	if r.Profile == ProfileRamp {
+		rate = fmt.Sprintf("%g/s -> %g/s", r.RampFrom, r.Rate)
+	}
+
+	_, _ = fmt.Fprintf(w, "%s %s at %s for %s\n\n", r.Method, r.URL, rate, r.Elapsed.Round(time.Millisecond))
+
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(t, "PHASE\tAT\tREQUESTS\tP50\tP90\tP95\tP99\tMAX\tSTATUS\tTIMEOUTS\tERRORS")
+
+	rows := append([]Phase{}, r.Phases...)
+
+	if len(rows) > 1 {
+		rows = append(rows, Phase{Name: "total", At: -1, Stats: r.Total})
+	}
+
+	for _, p := range rows {
+
+		at := "-"
+
+		if p.At >= 0 {
+			at = "+" + p.At.Round(time.Millisecond).String()
+		}
+
+		name := p.Name
+
+		if len(p.Error) > 0 {
+			name += " (failed)"
+		}
+
+		l := p.Latency
+
+		_, _ = fmt.Fprintf(t, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n", name, at, p.Requests,
+			round(l.P50), round(l.P90), round(l.P95), round(l.P99), round(l.Max), p.statuses(), p.Timeouts, p.errors())
+	}
+
+	err := t.Flush()
+
+	if err != nil {
+		return err
+	}
+
+	if len(r.Total.Errors) > 0 {
+		_, _ = fmt.Fprintln(w, "\nErrors:")
+
+		keys := make([]string, 0, len(r.Total.Errors))
+
+		for k := range r.Total.Errors {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			_, _ = fmt.Fprintf(w, "  %d  %s\n", r.Total.Errors[k], k)
+		}
+	}
+
+	return nil
+}
+
+func (s Stats) statuses() string {
+
+	if len(s.Status) == 0 {
+		return "-"
+	}
+
+	codes := make([]int, 0, len(s.Status))
+
+	for k := range s.Status {
+		codes = append(codes, k)
+	}
+
+	sort.Ints(codes)
+
+	v := make([]string, 0, len(codes))
+
+	for _, k := range codes {
+		v = append(v, fmt.Sprintf("%d:%d", k, s.Status[k]))
+	}
+
+	return strings.Join(v, " ")
+}
+
+func (s Stats) errors() int {
+
+	n := 0
+
+	for _, v := range s.Errors {
+		n += v
+	}
+
+	return n
+}
+
+func round(d time.Duration) string {
+
+	if d == 0 {
+		return "-"
+	}
+
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+
+	return d.Round(100 * time.Microsecond).String()
+}
@@ -17,10 +17,16 @@ limitations under the License.
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -51,8 +57,37 @@ const (
 	TRACE = "TRACE"
 )
 
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+var levels = map[string]int{
+	TRACE: 0,
+	DEBUG: 1,
+	INFO:  2,
+	WARN:  3,
+	ERROR: 4,
+	FATAL: 5,
+}
+
 var log = NewLoggers()
 
+// Fields are the structured context of a log line, written as keys in json format.
+type Fields map[string]interface{}
+
+// Config selects the minimum level, the format and the output (stdout, stderr or a file path) of logs.
+type Config struct {
+	Level  string
+	Format string
+	Output string
+}
+
 type ILoggers interface {
 	Debug(message interface{})
 	Info(message interface{})
@@ -61,17 +96,112 @@ type ILoggers interface {
 	Fatal(message interface{})
 	Trace(message interface{})
 	Log(level string, message interface{})
+	LogWithFields(level string, message interface{}, fields Fields)
 	Spinner(message string) func()
 }
 
 type Loggers struct {
 	spinner *spinner.Spinner
+	level   int
+	format  string
+	out     io.Writer
+	color   bool
+	sync.Mutex
 }
 
 func NewLoggers() ILoggers {
 	return &Loggers{
 		spinner: spinner.New(spinnerFrames, 100*time.Millisecond),
+		format:  FormatText,
+		out:     os.Stdout,
+		color:   true,
+	}
+}
+
+// Configure replaces the package logger. Colors and spinners are only used for text logs on stdout.
+func Configure(c Config) error {
+
+	loggers := &Loggers{
+		spinner: spinner.New(spinnerFrames, 100*time.Millisecond),
+		format:  FormatText,
+		out:     os.Stdout,
+		color:   true,
+	}
+
+	if len(c.Level) > 0 {
+		level, ok := levels[strings.ToUpper(c.Level)]
+
+		if !ok && strings.EqualFold(c.Level, "warn") {
+			level, ok = levels[WARN], true
+		}
+
+		if !ok {
+			return errors.Errorf("Unexpected log level given: %s. Available: 'trace', 'debug', 'info', 'warn', 'error', 'fatal'", c.Level)
+		}
+
+		loggers.level = level
+	}
+
+	switch c.Format {
+	case "", FormatText:
+	case FormatJson:
+		loggers.format = FormatJson
+		loggers.color = false
+	default:
+		return errors.Errorf("Unexpected log format given: %s. Available: '%s', '%s'", c.Format, FormatText, FormatJson)
+	}
+
+	switch c.Output {
+	case "", OutputStdout:
+	case OutputStderr:
+		loggers.out = os.Stderr
+		loggers.color = false
+	default:
+		f, err := os.OpenFile(c.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+		if err != nil {
+			return errors.Wrapf(err, "Log file can not opened: %s", c.Output)
+		}
+
+		loggers.out = f
+		loggers.color = false
 	}
+
+	log = loggers
+
+	return nil
+}
+
+// Close closes the log file given as output. Later logs are discarded, stdout and stderr are left open.
+func Close() error {
+
+	l, ok := log.(*Loggers)
+
+	if !ok {
+		return nil
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	f, ok := l.out.(*os.File)
+
+	if !ok || f == os.Stdout || f == os.Stderr {
+		return nil
+	}
+
+	l.out = ioutil.Discard
+
+	return f.Close()
+}
+
+// Decorated reports whether logs are colored text on stdout, where banners and spinners belong.
+func Decorated() bool {
+	if l, ok := log.(*Loggers); ok {
+		return l.color
+	}
+
+	return true
 }
 
 func Debug(message interface{}) {
@@ -128,7 +258,61 @@ func Log(level string, message interface{}) {
 }
 
 func (loggers *Loggers) Log(level string, message interface{}) {
-	loggers.spinner.Stop()
+	loggers.LogWithFields(level, message, nil)
+}
+
+func LogWithFields(level string, message interface{}, fields Fields) {
+	log.LogWithFields(level, message, fields)
+}
+
+func InfoWithFields(message interface{}, fields Fields) {
+	log.LogWithFields(INFO, message, fields)
+}
+
+func DebugWithFields(message interface{}, fields Fields) {
+	log.LogWithFields(DEBUG, message, fields)
+}
+
+func (loggers *Loggers) LogWithFields(level string, message interface{}, fields Fields) {
+
+	if levels[level] < loggers.level {
+		return
+	}
+
+	loggers.Lock()
+	defer loggers.Unlock()
+
+	if loggers.color {
+		loggers.spinner.Stop()
+	}
+
+	if loggers.format == FormatJson {
+
+		line := Fields{}
+
+		for k, v := range fields {
+			line[k] = v
+		}
+
+		line["time"] = time.Now().Format(time.RFC3339Nano)
+		line["level"] = level
+		line["message"] = fmt.Sprint(message)
+
+		body, err := json.Marshal(line)
+
+		if err != nil {
+			body, _ = json.Marshal(Fields{"level": ERROR, "message": err.Error()})
+		}
+
+		_, _ = fmt.Fprintln(loggers.out, string(body))
+
+		return
+	}
+
+	if !loggers.color {
+		_, _ = fmt.Fprintf(loggers.out, "%s ⇨ %s\n", level, message)
+		return
+	}
 
 	c := color.New(color.FgWhite)
 
@@ -140,7 +324,7 @@ func (loggers *Loggers) Log(level string, message interface{}) {
 		c = color.New(color.Italic)
 	}
 
-	_, _ = c.Println(fmt.Sprintf("⇨ %s", message))
+	_, _ = c.Fprintln(loggers.out, fmt.Sprintf("⇨ %s", message))
 }
 
 func Spinner(message string) func() {
@@ -148,6 +332,12 @@ func Spinner(message string) func() {
 }
 
 func (loggers *Loggers) Spinner(message string) func() {
+
+	if !loggers.color {
+		loggers.Log(DEBUG, message)
+		return func() {}
+	}
+
 	loggers.spinner.Suffix = " " + message
 	_ = loggers.spinner.Color("fgGreen")
 	loggers.spinner.Start()
@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	AccessLogFormatCommon   = "common"
+	AccessLogFormatCombined = "combined"
+	AccessLogFormatJson     = "json"
+)
+
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// upstreamTimeKey is the user value holding how long a `redirect` result waited for the remote host.
+const upstreamTimeKey = "gaos.upstream"
+
+type AccessLog struct {
+	Format string `json:"format"`
+	Path   string `json:"path"`
+}
+
+type AccessLogEntry struct {
+	Time      string  `json:"time"`
+	Service   string  `json:"service"`
+	Client    string  `json:"client"`
+	Method    string  `json:"method"`
+	Uri       string  `json:"uri"`
+	Protocol  string  `json:"protocol"`
+	Status    int     `json:"status"`
+	Bytes     int     `json:"bytes"`
+	Referer   string  `json:"referer"`
+	UserAgent string  `json:"user_agent"`
+	RequestId string  `json:"request_id"`
+	Route     string  `json:"route"`
+	Scenario  string  `json:"scenario"`
+	Action    string  `json:"action"`
+	Elapsed   float64 `json:"elapsed"`
+	Upstream  float64 `json:"upstream,omitempty"`
+}
+
+type accessLogger struct {
+	format string
+	out    io.Writer
+	sync.Mutex
+}
+
+func newAccessLogger(a *AccessLog) (*accessLogger, io.Closer, error) {
+
+	format := a.Format
+
+	if len(format) == 0 {
+		format = AccessLogFormatCombined
+	}
+
+	if format != AccessLogFormatCommon && format != AccessLogFormatCombined && format != AccessLogFormatJson {
+		return nil, nil, errors.Errorf("Unexpected access log format given: %s. Available: '%s', '%s', '%s'", format, AccessLogFormatCommon, AccessLogFormatCombined, AccessLogFormatJson)
+	}
+
+	switch a.Path {
+	case "", "stdout":
+		return &accessLogger{format: format, out: os.Stdout}, nil, nil
+	case "stderr":
+		return &accessLogger{format: format, out: os.Stderr}, nil, nil
+	}
+
+	f, err := os.OpenFile(a.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Access log file can not opened: %s", a.Path)
+	}
+
+	return &accessLogger{format: format, out: f}, f, nil
+}
+
+func (l *accessLogger) write(ctx *fasthttp.RequestCtx, service, route string, scenario Scenario, action Action, start time.Time, elapsed time.Duration) {
+
+	e := AccessLogEntry{
+		Service:   service,
+		Client:    ctx.RemoteIP().String(),
+		Method:    string(ctx.Method()),
+		Uri:       string(ctx.RequestURI()),
+		Protocol:  string(ctx.Request.Header.Protocol()),
+		Status:    ctx.Response.StatusCode(),
+		Bytes:     responseBytes(ctx),
+		Referer:   string(ctx.Referer()),
+		UserAgent: string(ctx.UserAgent()),
+		RequestId: string(ctx.Request.Header.Peek("X-Request-Id")),
+		Route:     route,
+		Scenario:  scenario.key,
+		Action:    action.name,
+		Elapsed:   elapsed.Seconds(),
+	}
+
+	if v, ok := ctx.UserValue(upstreamTimeKey).(time.Duration); ok {
+		e.Upstream = v.Seconds()
+	}
+
+	var line string
+
+	switch l.format {
+	case AccessLogFormatJson:
+		e.Time = start.Format(time.RFC3339Nano)
+
+		body, err := json.Marshal(e)
+
+		if err != nil {
+			return
+		}
+
+		line = string(body)
+
+	case AccessLogFormatCommon:
+		line = common(e, start)
+
+	default:
+		line = fmt.Sprintf("%s %q %q request_id=%q scenario=%q action=%q upstream_time=%s",
+			common(e, start), dash(e.Referer), dash(e.UserAgent), e.RequestId, e.Scenario, e.Action, upstreamTime(e))
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	_, _ = fmt.Fprintln(l.out, line)
+}
+
+// responseBytes returns the body size. Streamed bodies are sent after the line is written and reading them here
+// would buffer the stream, so only their declared length is known.
+func responseBytes(ctx *fasthttp.RequestCtx) int {
+
+	if ctx.Response.IsBodyStream() {
+		if n := ctx.Response.Header.ContentLength(); n > 0 {
+			return n
+		}
+
+		return 0
+	}
+
+	return len(ctx.Response.Body())
+}
+
+func common(e AccessLogEntry, start time.Time) string {
+
+	bytes := "-"
+
+	if e.Bytes > 0 {
+		bytes = fmt.Sprint(e.Bytes)
+	}
+
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s", e.Client, start.Format(clfTimeFormat), e.Method, e.Uri, e.Protocol, e.Status, bytes)
+}
+
+func upstreamTime(e AccessLogEntry) string {
+	if e.Upstream == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.3f", e.Upstream)
+}
+
+func dash(v string) string {
+	if len(v) == 0 {
+		return "-"
+	}
+
+	return v
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestAccessLogFormats(t *testing.T) {
+
+	start := time.Date(2026, time.October, 19, 10, 4, 12, 0, time.UTC)
+	scenario := Scenario{key: "failing"}
+	action := Action{name: ActionIgnore}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: AccessLogFormatCommon,
+			want:   `127.0.0.1 - - [19/Oct/2026:10:04:12 +0000] "GET /payments/1?debug=1 HTTP/1.1" 500 4`,
+		},
+		{
+			format: AccessLogFormatCombined,
+			want:   `127.0.0.1 - - [19/Oct/2026:10:04:12 +0000] "GET /payments/1?debug=1 HTTP/1.1" 500 4 "http://shop" "curl/8.0" request_id="abc" scenario="failing" action="ignore" upstream_time=0.250`,
+		},
+		{
+			format: AccessLogFormatJson,
+			want:   `{"time":"2026-10-19T10:04:12Z","service":"payment","client":"127.0.0.1","method":"GET","uri":"/payments/1?debug=1","protocol":"HTTP/1.1","status":500,"bytes":4,"referer":"http://shop","user_agent":"curl/8.0","request_id":"abc","route":"/payments/{id}","scenario":"failing","action":"ignore","elapsed":0.5,"upstream":0.25}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+
+			ctx := accessContext()
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			ctx.SetBodyString("boom")
+			ctx.SetUserValue(upstreamTimeKey, 250*time.Millisecond)
+
+			var out bytes.Buffer
+
+			l := &accessLogger{format: tt.format, out: &out}
+			l.write(ctx, "payment", "/payments/{id}", scenario, action, start, 500*time.Millisecond)
+
+			if got := strings.TrimSuffix(out.String(), "\n"); got != tt.want {
+				t.Errorf("line = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogStream(t *testing.T) {
+
+	stream := &countingReader{r: strings.NewReader("data: event\n\n")}
+
+	ctx := accessContext()
+	ctx.SetBodyStream(stream, -1)
+
+	var out bytes.Buffer
+
+	l := &accessLogger{format: AccessLogFormatJson, out: &out}
+	l.write(ctx, "events", "/events", Scenario{}, Action{}, time.Now(), 0)
+
+	if stream.n > 0 {
+		t.Errorf("access log read %d bytes of the stream", stream.n)
+	}
+
+	e := AccessLogEntry{}
+
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Bytes != 0 {
+		t.Errorf("bytes = %d, want 0 for a stream of unknown length", e.Bytes)
+	}
+}
+
+func accessContext() *fasthttp.RequestCtx {
+
+	req := &fasthttp.Request{}
+	req.SetRequestURI("/payments/1?debug=1")
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.Header.SetReferer("http://shop")
+	req.Header.SetUserAgent("curl/8.0")
+	req.Header.Set("X-Request-Id", "abc")
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(req, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, nil)
+
+	return ctx
+}
+
+type countingReader struct {
+	r *strings.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of executables, replaced by a virtual clock to simulate scenarios.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time                         { return time.Now() }
+func (wallClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (wallClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// virtualClock never waits: Sleep moves the time forward, and After fires at once while
+// remembering the latest deadline, which settle moves the time to.
+type virtualClock struct {
+	now      time.Time
+	deadline time.Time
+	sync.Mutex
+}
+
+func (c *virtualClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.now
+}
+
+func (c *virtualClock) Sleep(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func (c *virtualClock) After(d time.Duration) <-chan time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	t := c.now.Add(d)
+
+	if t.After(c.deadline) {
+		c.deadline = t
+	}
+
+	ch := make(chan time.Time, 1)
+	ch <- t
+
+	return ch
+}
+
+func (c *virtualClock) settle() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.deadline.After(c.now) {
+		c.now = c.deadline
+	}
+}
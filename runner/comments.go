@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+)
+
+// StripComments removes `// line` and `/* block */` comments outside string literals from a scenario document.
+// Line breaks are kept, so parse errors still point at the right line.
+func StripComments(source []byte) []byte {
+
+	var b bytes.Buffer
+
+	quoted := false
+
+	for i := 0; i < len(source); i++ {
+
+		c := source[i]
+
+		if quoted {
+			b.WriteByte(c)
+
+			if c == '\\' && i+1 < len(source) {
+				b.WriteByte(source[i+1])
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+
+			continue
+		}
+
+		if c == '"' {
+			quoted = true
+			b.WriteByte(c)
+			continue
+		}
+
+		if c == '/' && i+1 < len(source) && source[i+1] == '/' {
+
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+
+			if i < len(source) {
+				b.WriteByte('\n')
+			}
+
+			continue
+		}
+
+		if c == '/' && i+1 < len(source) && source[i+1] == '*' {
+
+			i += 2
+
+			for i < len(source) && !(source[i] == '*' && i+1 < len(source) && source[i+1] == '/') {
+				if source[i] == '\n' {
+					b.WriteByte('\n')
+				}
+
+				i++
+			}
+
+			i++
+
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.Bytes()
+}
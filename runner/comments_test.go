@@ -0,0 +1,45 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: `{"a": 1}`, want: `{"a": 1}`},
+		{source: "{\n  // port of the service\n  \"port\": 8080\n}", want: "{\n  \n  \"port\": 8080\n}"},
+		{source: `{"a": 1 /* inline */, "b": 2}`, want: `{"a": 1 , "b": 2}`},
+		{source: "{/* two\nlines */\"a\": 1}", want: "{\n\"a\": 1}"},
+		{source: `{"url": "http://host//path"}`, want: `{"url": "http://host//path"}`},
+		{source: `{"glob": "/* not a comment */"}`, want: `{"glob": "/* not a comment */"}`},
+		{source: `{"quote": "say \"// hi\""} // trailing`, want: `{"quote": "say \"// hi\""} `},
+		{source: `{"a": 1} /* unterminated`, want: `{"a": 1} `},
+		{source: "// only a comment", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := string(StripComments([]byte(tt.source))); got != tt.want {
+			t.Errorf("StripComments(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
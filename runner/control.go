@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"net"
+	"sort"
+	"strconv"
+)
+
+// ServiceState is a running service, the port it is bound to and the state of its routes.
+type ServiceState struct {
+	Name   string       `json:"name"`
+	Type   string       `json:"type"`
+	Port   int32        `json:"port"`
+	Routes []RouteState `json:"routes"`
+}
+
+// RouteState is the active scenario of a route and its request counters since start or the last reset.
+type RouteState struct {
+	Route    string `json:"route"`
+	Method   string `json:"method,omitempty"`
+	Scenario string `json:"scenario"`
+	Name     string `json:"name,omitempty"`
+	Requests int    `json:"requests"`
+	Accepted int    `json:"accepted"`
+	Ignored  int    `json:"ignored"`
+}
+
+// ScenarioChange switches the scenario of a running route.
+type ScenarioChange struct {
+	Route    string `json:"route"`
+	Scenario string `json:"scenario"`
+}
+
+// Services returns the state of every running http and grpc service, sorted by name and route.
+func (g *Runner) Services() []ServiceState {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	result := make([]ServiceState, 0, len(g.methods))
+
+	for name, methods := range g.methods {
+
+		state := ServiceState{Name: name, Routes: make([]RouteState, 0, len(methods))}
+
+		service := g.Service[name]
+
+		if service != nil {
+			state.Type = service.Type
+			state.Port = service.Port
+		}
+
+		if _, port, err := net.SplitHostPort(g.addresses[name]); err == nil {
+			if p, err := strconv.ParseInt(port, 10, 32); err == nil {
+				state.Port = int32(p)
+			}
+		}
+
+		if len(state.Type) == 0 {
+			state.Type = ServiceTypeHTTP
+		}
+
+		for path, method := range methods {
+
+			method.Lock()
+
+			route := RouteState{
+				Route:    path,
+				Scenario: method.key,
+				Name:     method.Name,
+				Requests: method.accepted + method.ignored,
+				Accepted: method.accepted,
+				Ignored:  method.ignored,
+			}
+
+			method.Unlock()
+
+			if service != nil {
+				route.Method = service.Path[path].Method
+			}
+
+			state.Routes = append(state.Routes, route)
+		}
+
+		sort.Slice(state.Routes, func(i, j int) bool {
+			return state.Routes[i].Route < state.Routes[j].Route
+		})
+
+		result = append(result, state)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// Reset switches every running route back to its configured scenario, and restarts the `limit` and `rate`
+// counters of scenarios and the request counters of routes.
+func (g *Runner) Reset() {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, scenario := range g.Scenario {
+		for _, reset := range scenario.resets {
+			reset()
+		}
+	}
+
+	for name, methods := range g.methods {
+		for path, method := range methods {
+
+			scenario, ok := g.Scenario[g.Service[name].Path[path].Scenario]
+
+			if !ok {
+				continue
+			}
+
+			method.Lock()
+			method.Scenario = *scenario
+			method.accepted = 0
+			method.ignored = 0
+			method.Unlock()
+
+			g.Metrics.setActiveScenario(name, path, scenario.key)
+		}
+	}
+
+	logger.Info("Routes are reset to their scenarios")
+}
+
+func (g *Runner) servicesHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		g.writeJSON(ctx, g.Services())
+	}
+}
+
+func (g *Runner) scenarioHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+
+		var change ScenarioChange
+
+		err := json.Unmarshal(ctx.PostBody(), &change)
+
+		if err != nil {
+			g.badRequest(ctx, errors.Wrap(err, "Scenario change can not parsed"))
+			return
+		}
+
+		err = g.SetScenario(fmt.Sprint(ctx.UserValue("service")), change.Route, change.Scenario)
+
+		if err != nil {
+			g.notFound(ctx, err)
+			return
+		}
+
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+}
+
+func (g *Runner) resetHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		g.Reset()
+
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+}
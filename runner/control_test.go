@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+const controlScenario = `{
+  "service": {
+    "search": {
+      "port": 0,
+      "path": {
+        "/api": { "scenario": "limited", "method": "GET" }
+      }
+    }
+  },
+  "scenario": {
+    "limited": {
+      "limit": 2,
+      "accept": { "status": 200, "result": { "type": "static", "content": { "name": "gaos" } } },
+      "ignore": { "status": 503, "result": { "type": "static", "content": { "error": "limited" } } }
+    }
+  }
+}`
+
+func TestServicesReportsBoundPort(t *testing.T) {
+
+	g, addresses := startControlRunner(t)
+
+	services := g.Services()
+
+	if len(services) != 1 {
+		t.Fatalf("Services() = %+v, want one service", services)
+	}
+
+	if want := addresses["search"]; services[0].Port == 0 || !strings.HasSuffix(want, fmt.Sprintf(":%d", services[0].Port)) {
+		t.Errorf("port = %d, want the port of %s", services[0].Port, want)
+	}
+}
+
+func TestReset(t *testing.T) {
+
+	g, addresses := startControlRunner(t)
+
+	url := "http://" + addresses["search"] + "/api"
+
+	tests := []struct {
+		reset  bool
+		status []int
+	}{
+		{status: []int{200, 503, 503}},
+		{reset: true, status: []int{200, 503}},
+	}
+
+	for _, tt := range tests {
+
+		if tt.reset {
+			g.Reset()
+		}
+
+		for i, want := range tt.status {
+			if got := get(t, url); got != want {
+				t.Errorf("request #%d after reset %t = %d, want %d", i+1, tt.reset, got, want)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			get(t, url)
+		}()
+
+		go func() {
+			defer wg.Done()
+			g.Reset()
+		}()
+	}
+
+	wg.Wait()
+}
+
+func startControlRunner(t *testing.T) (*Runner, map[string]string) {
+
+	g, err := Load(strings.NewReader(controlScenario))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	addresses, err := g.Start(ctx)
+
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		_ = g.Shutdown()
+	})
+
+	return g, addresses
+}
+
+func get(t *testing.T, url string) int {
+
+	status, _, err := fasthttp.Get(nil, url)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	return status
+}
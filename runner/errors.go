@@ -17,10 +17,30 @@ limitations under the License.
 package runner
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// HeaderGaosError marks responses gaos produced itself, as opposed to errors injected by a scenario.
+const HeaderGaosError = "X-Gaos-Error"
+
+const (
+	ErrorNotFound         = "not-found"
+	ErrorMethodNotAllowed = "method-not-allowed"
+	ErrorBadRequest       = "bad-request"
+	ErrorInternal         = "internal"
+)
+
+// Errors overrides the responses of a service for requests gaos can not serve.
+type Errors struct {
+	NotFound         *Action `json:"notFound"`
+	MethodNotAllowed *Action `json:"methodNotAllowed"`
+	Internal         *Action `json:"internal"`
+}
+
 type GaosError struct {
 	Message string `json:"message"`
 	Cause   error  `json:"cause"`
@@ -30,6 +50,21 @@ func (e GaosError) Error() string {
 	return fmt.Sprintf("%s Cause: {%+v}", e.Message, e.Cause)
 }
 
+// MarshalJSON writes the cause as its message, which error values do not marshal on their own.
+func (e GaosError) MarshalJSON() ([]byte, error) {
+
+	v := struct {
+		Message string `json:"message"`
+		Cause   string `json:"cause,omitempty"`
+	}{Message: e.Message}
+
+	if e.Cause != nil {
+		v.Cause = e.Cause.Error()
+	}
+
+	return json.Marshal(v)
+}
+
 func WrapGaosError(cause error, message string) error {
 	err := GaosError{
 		Message: message,
@@ -38,3 +73,74 @@ func WrapGaosError(cause error, message string) error {
 
 	return err
 }
+
+func (e *Errors) action(kind string) *Action {
+
+	if e == nil {
+		return nil
+	}
+
+	switch kind {
+	case ErrorNotFound:
+		return e.NotFound
+	case ErrorMethodNotAllowed:
+		return e.MethodNotAllowed
+	case ErrorInternal:
+		return e.Internal
+	}
+
+	return nil
+}
+
+// respondError writes the error response of the given kind, using the custom action of the service when one is given.
+func (g *Runner) respondError(ctx *fasthttp.RequestCtx, e *Errors, kind string, status int, cause error) {
+
+	allow := string(ctx.Response.Header.Peek(fasthttp.HeaderAllow))
+
+	ctx.Response.Reset()
+
+	defer func() {
+		ctx.Response.Header.Set(HeaderGaosError, kind)
+
+		if kind == ErrorMethodNotAllowed && len(allow) > 0 {
+			ctx.Response.Header.Set(fasthttp.HeaderAllow, allow)
+		}
+	}()
+
+	if a := e.action(kind); a != nil {
+
+		action := *a
+
+		if action.Status == 0 {
+			action.Status = status
+		}
+
+		if err := action.Execute(ctx); err == nil {
+			ctx.SetStatusCode(action.Status)
+
+			return
+		}
+
+		ctx.Response.Reset()
+	}
+
+	body, _ := json.Marshal(WrapGaosError(cause, errorMessage(kind)))
+
+	ctx.SetBody(body)
+	ctx.SetContentType(runtime.ContentTypeJSON)
+	ctx.SetStatusCode(status)
+}
+
+func errorMessage(kind string) string {
+
+	switch kind {
+	case ErrorNotFound:
+		return "Route not found"
+	case ErrorMethodNotAllowed:
+		return "Method not allowed"
+	case ErrorBadRequest:
+		return "Bad request"
+	}
+
+	return "Occurred http error"
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRespondError(t *testing.T) {
+
+	custom := &Errors{NotFound: &Action{Result: Result{Type: ResultTypeStatic, Content: map[string]interface{}{"error": "gone"}}}}
+
+	tests := []struct {
+		name   string
+		errors *Errors
+		kind   string
+		status int
+		cause  error
+		allow  string
+		body   string
+	}{
+		{
+			name:   "not found",
+			kind:   ErrorNotFound,
+			status: fasthttp.StatusNotFound,
+			cause:  errors.Wrap(errors.New("no route for /missing"), "lookup failed"),
+			body:   `{"message":"Route not found","cause":"no route for /missing"}`,
+		},
+		{
+			name:   "method not allowed",
+			kind:   ErrorMethodNotAllowed,
+			status: fasthttp.StatusMethodNotAllowed,
+			allow:  "GET, POST",
+			body:   `{"message":"Method not allowed"}`,
+		},
+		{
+			name:   "custom",
+			errors: custom,
+			kind:   ErrorNotFound,
+			status: fasthttp.StatusNotFound,
+			body:   `{"error":"gone"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := &fasthttp.RequestCtx{}
+
+			if len(tt.allow) > 0 {
+				ctx.Response.Header.Set(fasthttp.HeaderAllow, tt.allow)
+			}
+
+			(&Runner{}).respondError(ctx, tt.errors, tt.kind, tt.status, tt.cause)
+
+			if got := string(ctx.Response.Body()); got != tt.body {
+				t.Errorf("body = %s, want %s", got, tt.body)
+			}
+
+			if got := ctx.Response.StatusCode(); got != tt.status {
+				t.Errorf("status = %d, want %d", got, tt.status)
+			}
+
+			if got := string(ctx.Response.Header.Peek(HeaderGaosError)); got != tt.kind {
+				t.Errorf("%s = %s, want %s", HeaderGaosError, got, tt.kind)
+			}
+
+			if got := string(ctx.Response.Header.Peek(fasthttp.HeaderAllow)); got != tt.allow {
+				t.Errorf("Allow = %s, want %s", got, tt.allow)
+			}
+		})
+	}
+}
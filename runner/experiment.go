@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// Experiment switches routes of a running runner through timed phases.
+type Experiment struct {
+	Phases []*ExperimentPhase `json:"phases"`
+}
+
+// ExperimentPhase binds routes to scenarios for a duration. Routes bound by other phases of the experiment
+// run their configured scenario during the phase.
+type ExperimentPhase struct {
+	Name     string                       `json:"name"`
+	Duration string                       `json:"duration"`
+	Routes   map[string]map[string]string `json:"routes"`
+	duration time.Duration
+}
+
+type binding struct {
+	service string
+	route   string
+}
+
+// RunExperiment runs the phases of the named experiment, or of the only one defined, on the running
+// services, and switches every route it touched back to its configured scenario when the experiment ends
+// or ctx is done.
+func (g *Runner) RunExperiment(ctx context.Context, name string) error {
+
+	name, e, err := g.experiment(name)
+
+	if err != nil {
+		return err
+	}
+
+	routes, err := g.validateExperiment(name, e)
+
+	if err != nil {
+		return err
+	}
+
+	for _, b := range routes {
+		if _, err := g.method(b.service, b.route); err != nil {
+			return errors.Wrapf(err, "Experiment [%s] can not bound", name)
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Experiment [%s] started with %d phases", name, len(e.Phases)))
+
+	defer g.revert(name, routes)
+
+	for i, p := range e.Phases {
+
+		for _, b := range routes {
+
+			scenario, ok := p.Routes[b.service][b.route]
+
+			if !ok {
+				scenario = g.Service[b.service].Path[b.route].Scenario
+			}
+
+			err := g.SetScenario(b.service, b.route, scenario)
+
+			if err != nil {
+				return errors.Wrapf(err, "Experiment [%s] phase [%s] can not applied", name, p.Name)
+			}
+		}
+
+		logger.Info(fmt.Sprintf("Experiment [%s] phase [%s] started (%d/%d) for %s", name, p.Name, i+1, len(e.Phases), p.duration))
+
+		timer := time.NewTimer(p.duration)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Warn(fmt.Sprintf("Experiment [%s] interrupted in phase [%s]", name, p.Name))
+
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Experiment [%s] finished", name))
+
+	return nil
+}
+
+// ValidateExperiment checks the named experiment, or the only one defined, against the scenario file
+// without starting servers: its phase durations, and the routes and scenarios the phases bind.
+func (g *Runner) ValidateExperiment(name string) error {
+
+	name, e, err := g.experiment(name)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = g.validateExperiment(name, e)
+
+	return err
+}
+
+func (g *Runner) experiment(name string) (string, *Experiment, error) {
+
+	if len(name) == 0 {
+
+		if len(g.Experiment) != 1 {
+			return "", nil, errors.Errorf("Experiment name must be given, %d experiments are defined", len(g.Experiment))
+		}
+
+		for k, v := range g.Experiment {
+			return k, v, nil
+		}
+	}
+
+	e, ok := g.Experiment[name]
+
+	if !ok || e == nil {
+		return "", nil, errors.Errorf("Experiment [%s] is not defined", name)
+	}
+
+	return name, e, nil
+}
+
+// validateExperiment parses phase durations and returns the routes the experiment binds, sorted.
+func (g *Runner) validateExperiment(name string, e *Experiment) ([]binding, error) {
+
+	if len(e.Phases) == 0 {
+		return nil, errors.Errorf("Experiment [%s] has no phases", name)
+	}
+
+	seen := map[binding]bool{}
+	var routes []binding
+
+	for i, p := range e.Phases {
+
+		if p == nil {
+			return nil, errors.Errorf("Experiment [%s] phase %d is empty", name, i+1)
+		}
+
+		if len(p.Name) == 0 {
+			p.Name = fmt.Sprintf("phase-%d", i+1)
+		}
+
+		d, err := time.ParseDuration(p.Duration)
+
+		if err != nil || d <= 0 {
+			return nil, errors.Errorf("Experiment [%s] phase [%s] duration must be positive. Value: %s", name, p.Name, p.Duration)
+		}
+
+		p.duration = d
+
+		for service, paths := range p.Routes {
+			for route, scenario := range paths {
+
+				s, ok := g.Service[service]
+
+				if !ok || s == nil {
+					return nil, errors.Errorf("Experiment [%s] phase [%s] service [%s] is not defined", name, p.Name, service)
+				}
+
+				if _, ok := s.Path[route]; !ok {
+					return nil, errors.Errorf("Experiment [%s] phase [%s] path [%s] is not defined for service [%s]", name, p.Name, route, service)
+				}
+
+				if _, ok := g.Scenario[scenario]; !ok {
+					return nil, errors.Errorf("Experiment [%s] phase [%s] scenario [%s] is not defined", name, p.Name, scenario)
+				}
+
+				b := binding{service: service, route: route}
+
+				if !seen[b] {
+					seen[b] = true
+					routes = append(routes, b)
+				}
+			}
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].service != routes[j].service {
+			return routes[i].service < routes[j].service
+		}
+
+		return routes[i].route < routes[j].route
+	})
+
+	return routes, nil
+}
+
+func (g *Runner) revert(name string, routes []binding) {
+
+	for _, b := range routes {
+
+		err := g.SetScenario(b.service, b.route, g.Service[b.service].Path[b.route].Scenario)
+
+		if err != nil && !g.running() {
+			logger.Info(fmt.Sprintf("Experiment [%s] routes are not reverted, servers are stopped", name))
+			return
+		}
+
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "Experiment [%s] route %s of [%s] can not reverted", name, b.route, b.service))
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Experiment [%s] routes reverted", name))
+}
+
+// running reports whether services are started and not shut down yet.
+func (g *Runner) running() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.methods != nil
+}
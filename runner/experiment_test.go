@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const experimentScenarios = `{
+  "service": {
+    "search": { "port": 0, "path": { "/api": { "method": "GET", "scenario": "ok" } } }
+  },
+  "scenario": {
+    "ok": { "accept": { "status": 200 } },
+    "error": { "accept": { "status": 500 } }
+  },
+  "experiment": {
+    "outage": { "phases": [
+      { "name": "down", "duration": "10ms", "routes": { "search": { "/api": "error" } } },
+      { "name": "up", "duration": "10ms" }
+    ] },
+    "no-phases": { "phases": [] },
+    "null-phase": { "phases": [null] },
+    "bad-duration": { "phases": [{ "duration": "soon" }] },
+    "zero-duration": { "phases": [{ "duration": "0s" }] },
+    "undefined-service": { "phases": [{ "duration": "1s", "routes": { "payment": { "/api": "error" } } }] },
+    "undefined-path": { "phases": [{ "duration": "1s", "routes": { "search": { "/other": "error" } } }] },
+    "undefined-scenario": { "phases": [{ "duration": "1s", "routes": { "search": { "/api": "erorr" } } }] }
+  }
+}`
+
+func TestValidateExperiment(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{name: "outage"},
+		{name: "", err: "Experiment name must be given, 8 experiments are defined"},
+		{name: "unknown", err: "Experiment [unknown] is not defined"},
+		{name: "no-phases", err: "Experiment [no-phases] has no phases"},
+		{name: "null-phase", err: "Experiment [null-phase] phase 1 is empty"},
+		{name: "bad-duration", err: "Experiment [bad-duration] phase [phase-1] duration must be positive. Value: soon"},
+		{name: "zero-duration", err: "Experiment [zero-duration] phase [phase-1] duration must be positive. Value: 0s"},
+		{name: "undefined-service", err: "Experiment [undefined-service] phase [phase-1] service [payment] is not defined"},
+		{name: "undefined-path", err: "Experiment [undefined-path] phase [phase-1] path [/other] is not defined for service [search]"},
+		{name: "undefined-scenario", err: "Experiment [undefined-scenario] phase [phase-1] scenario [erorr] is not defined"},
+	}
+
+	g, err := Load(strings.NewReader(experimentScenarios))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+
+		err := g.ValidateExperiment(tt.name)
+
+		if len(tt.err) == 0 && err != nil {
+			t.Errorf("ValidateExperiment(%q) failed: %v", tt.name, err)
+		}
+
+		if len(tt.err) > 0 && (err == nil || err.Error() != tt.err) {
+			t.Errorf("ValidateExperiment(%q) = %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestRunExperiment(t *testing.T) {
+
+	g, err := Load(strings.NewReader(experimentScenarios))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := g.RunExperiment(ctx, "outage"); err == nil || !strings.Contains(err.Error(), "Service [search] is not running") {
+		t.Errorf("RunExperiment() before Start = %v, want not running error", err)
+	}
+
+	if _, err := g.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	defer g.Shutdown()
+
+	if err := g.RunExperiment(ctx, "outage"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := g.ActiveScenario("search", "/api"); got != "ok" {
+		t.Errorf("ActiveScenario() after the experiment = %s, want ok", got)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"strings"
+	"time"
+)
+
+const explainBodyLimit = 60
+
+// Simulation configures the requests sent to a route by Explain.
+type Simulation struct {
+	Requests int
+	Interval time.Duration
+	Start    time.Time
+	Subject  string
+}
+
+// Step describes how a simulated request was handled.
+type Step struct {
+	Request    int           `json:"request"`
+	Time       time.Time     `json:"time"`
+	Elapsed    time.Duration `json:"elapsed"`
+	Scenario   string        `json:"scenario"`
+	Trigger    string        `json:"trigger,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	Action     string        `json:"action"`
+	Status     int           `json:"status"`
+	Body       string        `json:"body"`
+	Transition string        `json:"transition,omitempty"`
+}
+
+// Explain sends simulated requests to a route on a virtual clock, so `span`, `latency` and `duration`
+// never really wait, and reports what each request ran into. The route is a path template of the service
+// or a concrete path matching one; unmatched paths go to the fallback of the service if it has one.
+// Scenarios are resolved against the virtual clock, so the runner can not be started afterwards.
+func (g *Runner) Explain(service, route string, s Simulation) ([]Step, error) {
+
+	g.mutex.Lock()
+	running := g.methods != nil
+	g.mutex.Unlock()
+
+	if running {
+		return nil, errors.New("Scenarios can not be explained while the runner is running")
+	}
+
+	svc, ok := g.Service[service]
+
+	if !ok {
+		return nil, errors.Errorf("Service [%s] is not defined", service)
+	}
+
+	if s.Start.IsZero() {
+		s.Start = time.Now()
+	}
+
+	clock := &virtualClock{now: s.Start, deadline: s.Start}
+
+	g.clock = clock
+	g.initialize()
+
+	err := g.resolveScenarios()
+
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := g.explained(svc, service, route)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []Step
+
+	for i := 1; i <= s.Requests; i++ {
+
+		start := clock.Now()
+
+		scenario, action, done, t := method.execute(&Request{Subject: s.Subject, ctx: context.Background()})
+
+		for _, d := range done {
+			<-d
+		}
+
+		clock.settle()
+
+		step := Step{
+			Request:  i,
+			Time:     start,
+			Elapsed:  clock.Now().Sub(start),
+			Scenario: scenario.key,
+			Action:   action.name,
+		}
+
+		if t != nil {
+			step.Trigger = scenario.names[t.index]
+			step.Reason = t.err.Error()
+		}
+
+		step.Status, step.Body = describe(action)
+
+		if action.scenario != nil {
+			step.Transition = action.scenario.key
+		} else if _, ok := g.Scenario[action.Direct]; len(action.Direct) > 0 && !ok {
+			step.Transition = action.Direct + " (not defined)"
+		}
+
+		steps = append(steps, step)
+
+		clock.Sleep(s.Interval)
+	}
+
+	return steps, nil
+}
+
+// explained builds the method handling route, matching `{param}` segments of path templates.
+func (g *Runner) explained(svc *Service, service, route string) (*Method, error) {
+
+	path, ok := svc.Path[route]
+
+	if !ok {
+		best := ""
+
+		for template := range svc.Path {
+			if matchRoute(template, route) && (len(best) == 0 || moreSpecific(template, best)) {
+				best = template
+			}
+		}
+
+		if len(best) > 0 {
+			route, path, ok = best, svc.Path[best], true
+		}
+	}
+
+	if !ok {
+		if svc.Fallback != nil {
+			return g.fallback(svc, service, nil)
+		}
+
+		return nil, errors.Errorf("Path [%s] is not defined for service [%s]", route, service)
+	}
+
+	scenario, ok := g.Scenario[path.Scenario]
+
+	if !ok {
+		return nil, errors.Errorf("Scenario [%s] of path [%s] is not defined", path.Scenario, route)
+	}
+
+	return &Method{Scenario: *scenario, runner: g, service: service, path: route}, nil
+}
+
+func matchRoute(template, path string) bool {
+
+	t := strings.Split(strings.Trim(template, "/"), "/")
+	p := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range t {
+
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, ":*}") {
+			return true
+		}
+
+		if i >= len(p) {
+			return false
+		}
+
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		if segment != p[i] {
+			return false
+		}
+	}
+
+	return len(t) == len(p)
+}
+
+// moreSpecific reports whether template a wins over b for a path both match. Like the router, static segments
+// win over parameters, and parameters over catch-all ones, comparing segments from the left.
+func moreSpecific(a, b string) bool {
+
+	as := strings.Split(strings.Trim(a, "/"), "/")
+	bs := strings.Split(strings.Trim(b, "/"), "/")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if ka, kb := segmentKind(as[i]), segmentKind(bs[i]); ka != kb {
+			return ka < kb
+		}
+	}
+
+	if len(as) != len(bs) {
+		return len(as) > len(bs)
+	}
+
+	return a < b
+}
+
+// segmentKind orders path template segments by precedence: static, parameter, then catch-all.
+func segmentKind(segment string) int {
+
+	if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		return 0
+	}
+
+	if strings.HasSuffix(segment, ":*}") {
+		return 2
+	}
+
+	return 1
+}
+
+// describe returns the status and a body summary of an action without contacting upstreams.
+// A zero status means the upstream of a redirect decides it.
+func describe(action Action) (int, string) {
+
+	switch action.Result.Type {
+	case ResultTypeRedirect:
+
+		if v, ok := action.Result.Content.(map[string]interface{}); ok {
+			return 0, fmt.Sprintf("redirect to %v", v["host"])
+		}
+
+		return fasthttp.StatusOK, ""
+
+	case ResultTypeWebSocket:
+		return fasthttp.StatusSwitchingProtocols, "websocket stream"
+
+	case ResultTypeSSE:
+		return fasthttp.StatusOK, "event stream"
+	}
+
+	var ctx fasthttp.RequestCtx
+
+	ctx.Init(&fasthttp.Request{}, nil, nil)
+
+	err := action.Execute(&ctx)
+
+	if err != nil {
+		return fasthttp.StatusInternalServerError, err.Error()
+	}
+
+	body := []rune(strings.Join(strings.Fields(string(ctx.Response.Body())), " "))
+
+	if len(body) > explainBodyLimit {
+		body = append(body[:explainBodyLimit-3], []rune("...")...)
+	}
+
+	return ctx.Response.StatusCode(), string(body)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplain(t *testing.T) {
+
+	start := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	layout := "2006-01-02T15:04:05.999999Z"
+
+	tests := []struct {
+		name        string
+		scenarios   string
+		requests    int
+		interval    time.Duration
+		actions     []string
+		triggers    []string
+		elapsed     []time.Duration
+		scenario    []string
+		transitions []string
+	}{
+		{
+			name:      "rate",
+			scenarios: `"entry": {"rate": 3}`,
+			requests:  4,
+			actions:   []string{ActionAccept, ActionAccept, ActionIgnore, ActionAccept},
+			triggers:  []string{"", "", "rate", ""},
+		},
+		{
+			name:      "limit",
+			scenarios: `"entry": {"limit": 2}`,
+			requests:  3,
+			actions:   []string{ActionAccept, ActionIgnore, ActionIgnore},
+			triggers:  []string{"", "limit", "limit"},
+		},
+		{
+			name:      "latency",
+			scenarios: `"entry": {"latency": "500ms"}`,
+			requests:  2,
+			elapsed:   []time.Duration{500 * time.Millisecond, 500 * time.Millisecond},
+		},
+		{
+			name:      "duration",
+			scenarios: `"entry": {"duration": "2s", "latency": "100ms"}`,
+			requests:  2,
+			elapsed:   []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+		{
+			name:      "span",
+			scenarios: `"entry": {"start": "` + start.Add(time.Second).Format(layout) + `", "end": "` + start.Add(2*time.Second).Format(layout) + `"}`,
+			requests:  4,
+			interval:  time.Second,
+			actions:   []string{ActionIgnore, ActionAccept, ActionAccept, ActionIgnore},
+			triggers:  []string{"span", "", "", "span"},
+		},
+		{
+			name: "direct",
+			scenarios: `"entry": {"limit": 2, "ignore": {"direct": "recovering"}},
+				"recovering": {"accept": {"direct": "entry"}}`,
+			requests:    4,
+			scenario:    []string{"entry", "entry", "recovering", "entry"},
+			transitions: []string{"", "recovering", "entry", "recovering"},
+		},
+		{
+			name:        "direct to undefined",
+			scenarios:   `"entry": {"accept": {"direct": "ghost"}}`,
+			requests:    1,
+			transitions: []string{"ghost (not defined)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			g, err := Load(strings.NewReader(`{
+  "service": {"search": {"port": 8080, "path": {"/api": {"method": "GET", "scenario": "entry"}}}},
+  "scenario": {` + tt.scenarios + `}
+}`))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wall := time.Now()
+
+			steps, err := g.Explain("search", "/api", Simulation{Requests: tt.requests, Interval: tt.interval, Start: start})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if time.Since(wall) > time.Second {
+				t.Errorf("Explain waited %s, want a virtual clock", time.Since(wall))
+			}
+
+			var actions, triggers, scenarios, transitions []string
+			var elapsed []time.Duration
+
+			for _, s := range steps {
+				actions = append(actions, s.Action)
+				triggers = append(triggers, s.Trigger)
+				scenarios = append(scenarios, s.Scenario)
+				transitions = append(transitions, s.Transition)
+				elapsed = append(elapsed, s.Elapsed)
+			}
+
+			check := func(field string, got, want interface{}, given bool) {
+				if given && !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %v, want %v", field, got, want)
+				}
+			}
+
+			check("actions", actions, tt.actions, tt.actions != nil)
+			check("triggers", triggers, tt.triggers, tt.triggers != nil)
+			check("scenarios", scenarios, tt.scenario, tt.scenario != nil)
+			check("transitions", transitions, tt.transitions, tt.transitions != nil)
+			check("elapsed", elapsed, tt.elapsed, tt.elapsed != nil)
+		})
+	}
+}
+
+func TestExplainedRoute(t *testing.T) {
+
+	svc := &Service{Path: map[string]Path{
+		"/api/{id}/orders":  {Scenario: "ok"},
+		"/api/me/{sub}":     {Scenario: "ok"},
+		"/files/{path:*}":   {Scenario: "ok"},
+		"/files/{name}":     {Scenario: "ok"},
+		"/files/{name}/raw": {Scenario: "ok"},
+	}}
+
+	g := &Runner{Service: map[string]*Service{"search": svc}, Scenario: map[string]*Scenario{"ok": {}}}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/me/orders", want: "/api/me/{sub}"},
+		{path: "/api/42/orders", want: "/api/{id}/orders"},
+		{path: "/files/a.txt", want: "/files/{name}"},
+		{path: "/files/a.txt/raw", want: "/files/{name}/raw"},
+		{path: "/files/a/b/c", want: "/files/{path:*}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+
+			// Templates are kept in a map, so matching is repeated to catch an order dependency.
+			for i := 0; i < 20; i++ {
+
+				m, err := g.explained(svc, "search", tt.path)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if m.path != tt.want {
+					t.Fatalf("route = %s, want %s", m.path, tt.want)
+				}
+			}
+		})
+	}
+}
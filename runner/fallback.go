@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"github.com/pkg/errors"
+)
+
+// FallbackRoute is the route label of requests passed through to the fallback upstream.
+const FallbackRoute = "*"
+
+// Fallback passes requests matching no path of a service through to a real upstream.
+type Fallback struct {
+	Host     string `json:"host"`
+	Scenario string `json:"scenario"`
+}
+
+// fallback returns the method forwarding unmatched requests. The `accept` action of its scenario
+// always forwards to the upstream, while `ignore` injects the configured failure.
+func (g *Runner) fallback(service *Service, name string, access *accessLogger) (*Method, error) {
+
+	f := service.Fallback
+
+	if len(f.Host) == 0 {
+		return nil, errors.New("Fallback host must be given")
+	}
+
+	scenario := Scenario{}
+
+	if len(f.Scenario) > 0 {
+
+		v, ok := g.Scenario[f.Scenario]
+
+		if !ok {
+			return nil, errors.Errorf("Fallback scenario can not found: %s", f.Scenario)
+		}
+
+		scenario = *v
+	}
+
+	scenario.Accept = Action{
+		name:        ActionAccept,
+		passthrough: true,
+		Result: Result{
+			Type:    ResultTypeRedirect,
+			Content: map[string]interface{}{"host": f.Host},
+		},
+	}
+
+	scenario.Ignore.scenario = nil
+
+	return &Method{Scenario: scenario, runner: g, service: name, path: FallbackRoute, access: access}, nil
+}
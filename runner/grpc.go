@@ -0,0 +1,515 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+type GRPCMethod struct {
+	*Method
+	descriptor protoreflect.MethodDescriptor
+	types      resolver
+}
+
+// GRPCError is the result content of actions with a non-OK gRPC status.
+type GRPCError struct {
+	Message string            `json:"message"`
+	Details []json.RawMessage `json:"details"`
+}
+
+// resolver finds message types in the service descriptor set, then in well-known types such as google.rpc.ErrorInfo.
+type resolver struct {
+	*dynamicpb.Types
+}
+
+func (r resolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if t, err := protoregistry.GlobalTypes.FindMessageByName(name); err == nil {
+		return t, nil
+	}
+
+	return r.Types.FindMessageByName(name)
+}
+
+func (r resolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if t, err := protoregistry.GlobalTypes.FindMessageByURL(url); err == nil {
+		return t, nil
+	}
+
+	return r.Types.FindMessageByURL(url)
+}
+
+func (g *Runner) runToGRPCService(service *Service, name string) (string, error) {
+
+	files, err := readDescriptors(service.Descriptor)
+
+	if err != nil {
+		return "", err
+	}
+
+	types := resolver{dynamicpb.NewTypes(files)}
+
+	methods := map[string]*Method{}
+	handlers := map[string]*GRPCMethod{}
+
+	for path, value := range service.Path {
+
+		scenario, ok := g.Scenario[value.Scenario]
+
+		if !ok {
+			continue
+		}
+
+		fullName := strings.Replace(strings.TrimPrefix(path, "/"), "/", ".", 1)
+
+		d, err := files.FindDescriptorByName(protoreflect.FullName(fullName))
+
+		if err != nil {
+			return "", errors.Wrapf(err, "gRPC method [%s] is not defined in descriptor set", path)
+		}
+
+		md, ok := d.(protoreflect.MethodDescriptor)
+
+		if !ok {
+			return "", errors.Errorf("gRPC path [%s] is not a method. Expected: /package.Service/Method", path)
+		}
+
+		method := &Method{Scenario: *scenario, runner: g, service: name, path: path}
+
+		methods[path] = method
+		handlers[fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())] = &GRPCMethod{method, md, types}
+
+		g.Metrics.setActiveScenario(name, path, scenario.key)
+	}
+
+	options := []grpc.ServerOption{
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+
+			fullMethod, _ := grpc.MethodFromServerStream(stream)
+
+			m, ok := handlers[fullMethod]
+
+			if !ok {
+				return status.Errorf(codes.Unimplemented, "gaos: method %s is not mocked", fullMethod)
+			}
+
+			return m.Handle(stream)
+		}),
+	}
+
+	if service.TLS != nil {
+
+		config, err := g.tlsConfig(service.TLS)
+
+		if err != nil {
+			return "", err
+		}
+
+		options = append(options, grpc.Creds(credentials.NewTLS(config)))
+	}
+
+	ln, err := net.Listen("tcp4", fmt.Sprintf(":%d", service.Port))
+
+	if err != nil {
+		return "", err
+	}
+
+	s := grpc.NewServer(options...)
+
+	go func() {
+		err := s.Serve(ln)
+
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	g.track(server{name: fmt.Sprint(port), kind: "gRPC", listener: ln, shutdown: func() error {
+		s.GracefulStop()
+		return nil
+	}})
+
+	g.register(name, methods)
+
+	logger.Info(fmt.Sprintf("[%d] gRPC server started for [%s]", port, name))
+
+	return ln.Addr().String(), nil
+}
+
+// GRPCCode is a gRPC status code, given by number or name, e.g. `14` or `"UNAVAILABLE"`.
+type GRPCCode = codes.Code
+
+// httpCodes converts HTTP statuses of scenarios shared with HTTP services to gRPC codes.
+var httpCodes = map[int]codes.Code{
+	fasthttp.StatusBadRequest:          codes.InvalidArgument,
+	fasthttp.StatusUnauthorized:        codes.Unauthenticated,
+	fasthttp.StatusForbidden:           codes.PermissionDenied,
+	fasthttp.StatusNotFound:            codes.NotFound,
+	fasthttp.StatusConflict:            codes.Aborted,
+	fasthttp.StatusPreconditionFailed:  codes.FailedPrecondition,
+	fasthttp.StatusTooManyRequests:     codes.ResourceExhausted,
+	499:                                codes.Canceled,
+	fasthttp.StatusInternalServerError: codes.Internal,
+	fasthttp.StatusNotImplemented:      codes.Unimplemented,
+	fasthttp.StatusServiceUnavailable:  codes.Unavailable,
+	fasthttp.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// grpcCode returns `grpcStatus` if given, or the gRPC code of the HTTP `status`.
+func (a *Action) grpcCode() codes.Code {
+
+	if a.GRPCStatus != nil {
+		return *a.GRPCStatus
+	}
+
+	if a.Status == 0 || a.Status < fasthttp.StatusMultipleChoices {
+		return codes.OK
+	}
+
+	if code, ok := httpCodes[a.Status]; ok {
+		return code
+	}
+
+	if a.Status >= fasthttp.StatusInternalServerError {
+		return codes.Internal
+	}
+
+	return codes.Unknown
+}
+
+func readDescriptors(path string) (*protoregistry.Files, error) {
+
+	file, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "gRPC descriptor set can not read")
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+
+	err = proto.Unmarshal(file, set)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "gRPC descriptor set can not parsed")
+	}
+
+	files, err := protodesc.NewFiles(set)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "gRPC descriptor set can not resolved. Generate it with `protoc --include_imports --descriptor_set_out`")
+	}
+
+	return files, nil
+}
+
+func (m *GRPCMethod) Handle(stream grpc.ServerStream) error {
+
+	start := time.Now()
+
+	var received []proto.Message
+
+	for {
+		in := dynamicpb.NewMessage(m.descriptor.Input())
+
+		err := stream.RecvMsg(in)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		received = append(received, in)
+
+		if !m.descriptor.IsStreamingClient() {
+			break
+		}
+	}
+
+	m.runner.Metrics.requestStarted(m.service)
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+
+	parent := propagator.Extract(context.Background(), metadataCarrier(md))
+
+	service, method := path.Split(m.path)
+
+	spanCtx, span := m.runner.tracer.Start(parent, strings.TrimPrefix(m.path, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(strings.Trim(service, "/")),
+			semconv.RPCMethod(method),
+			attribute.String("gaos.service", m.service),
+		),
+	)
+
+	request := &Request{Subject: peerSubject(stream.Context())}
+
+	matchCtx, match := m.runner.tracer.Start(spanCtx, "gaos.match")
+
+	request.ctx = matchCtx
+
+	scenario, action, done := m.Execute(request)
+
+	match.SetAttributes(
+		attribute.String("gaos.scenario", scenario.key),
+		attribute.String("gaos.action", action.name),
+	)
+	match.End()
+
+	span.SetAttributes(
+		attribute.String("gaos.scenario", scenario.key),
+		attribute.String("gaos.action", action.name),
+	)
+
+	messages, err := action.GRPCResult(m.descriptor, m.types)
+
+	for _, d := range done {
+		<-d
+	}
+
+	if err == nil {
+		for _, message := range messages {
+			if err = stream.SendMsg(message); err != nil {
+				break
+			}
+		}
+	}
+
+	code := status.Code(err)
+	elapsed := time.Since(start)
+
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+
+	if code != codes.OK {
+		span.SetStatus(otelcodes.Error, code.String())
+	}
+
+	span.End()
+
+	logger.DebugWithFields(fmt.Sprintf("Service: %s | Method: %s | Executed: %s | Code: %s | Elapsed time: %dms", m.service, m.path, scenario.Name, code, elapsed/time.Millisecond), logger.Fields{
+		"service":  m.service,
+		"route":    m.path,
+		"path":     m.path,
+		"scenario": scenario.key,
+		"action":   action.name,
+		"status":   int(code),
+		"elapsed":  elapsed / time.Millisecond,
+	})
+
+	m.runner.Metrics.incrementEndpointCallCount(fasthttp.MethodPost, m.path)
+	m.runner.Metrics.requestFinished(m.service, m.path, scenario.key, action.name, int(code), elapsed)
+
+	header := map[string][]string{}
+
+	for k, v := range md {
+		header[http.CanonicalHeaderKey(k)] = v
+	}
+
+	body := journalBody(m.descriptor, received)
+
+	m.runner.Journal.Record(Entry{
+		Time:     start,
+		Service:  m.service,
+		Route:    m.path,
+		Method:   fasthttp.MethodPost,
+		Path:     m.path,
+		Header:   header,
+		Body:     body,
+		Subject:  request.Subject,
+		Scenario: scenario.key,
+		Action:   action.name,
+		Status:   int(code),
+		Elapsed:  elapsed,
+	})
+
+	return err
+}
+
+// GRPCResult converts the JSON result content to output messages, or a status error when the code is not OK.
+func (a *Action) GRPCResult(md protoreflect.MethodDescriptor, types resolver) ([]proto.Message, error) {
+
+	content, err := a.Result.body()
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if code := a.grpcCode(); code != codes.OK {
+
+		e := GRPCError{}
+
+		if len(content) > 0 {
+			if err := json.Unmarshal(content, &e); err != nil {
+				return nil, status.Errorf(codes.Internal, "gaos: gRPC error content can not parsed: %s", err)
+			}
+		}
+
+		s := &spb.Status{Code: int32(code), Message: e.Message}
+
+		for _, d := range e.Details {
+
+			detail := &anypb.Any{}
+
+			if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(d, detail); err != nil {
+				return nil, status.Errorf(codes.Internal, "gaos: gRPC error detail can not parsed: %s", err)
+			}
+
+			s.Details = append(s.Details, detail)
+		}
+
+		return nil, status.ErrorProto(s)
+	}
+
+	contents := []json.RawMessage{content}
+
+	if md.IsStreamingServer() && len(content) > 0 && content[0] == '[' {
+		if err := json.Unmarshal(content, &contents); err != nil {
+			return nil, status.Errorf(codes.Internal, "gaos: gRPC result content can not parsed: %s", err)
+		}
+	}
+
+	messages := make([]proto.Message, 0, len(contents))
+
+	for _, c := range contents {
+
+		message := dynamicpb.NewMessage(md.Output())
+
+		if len(c) > 0 {
+			if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(c, message); err != nil {
+				return nil, status.Errorf(codes.Internal, "gaos: gRPC result content can not converted to %s: %s", md.Output().FullName(), err)
+			}
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// journalBody returns the JSON form of the received message, or an array of them for client streaming methods.
+func journalBody(md protoreflect.MethodDescriptor, received []proto.Message) string {
+
+	messages := make([]json.RawMessage, 0, len(received))
+
+	for _, in := range received {
+
+		b, err := protojson.Marshal(in)
+
+		if err != nil {
+			continue
+		}
+
+		messages = append(messages, b)
+	}
+
+	if !md.IsStreamingClient() {
+
+		if len(messages) == 0 {
+			return ""
+		}
+
+		return string(messages[0])
+	}
+
+	b, _ := json.Marshal(messages)
+
+	return string(b)
+}
+
+// metadataCarrier propagates trace context through gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+
+	v := metadata.MD(c).Get(key)
+
+	if len(v) == 0 {
+		return ""
+	}
+
+	return v[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+
+	keys := make([]string, 0, len(c))
+
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func peerSubject(ctx context.Context) string {
+
+	p, ok := peer.FromContext(ctx)
+
+	if !ok {
+		return ""
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return ""
+	}
+
+	return info.State.PeerCertificates[0].Subject.String()
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestActionGRPCCode(t *testing.T) {
+
+	tests := []struct {
+		action string
+		want   codes.Code
+	}{
+		{action: `{}`, want: codes.OK},
+		{action: `{"status": 201}`, want: codes.OK},
+		{action: `{"status": 404}`, want: codes.NotFound},
+		{action: `{"status": 429}`, want: codes.ResourceExhausted},
+		{action: `{"status": 503}`, want: codes.Unavailable},
+		{action: `{"status": 507}`, want: codes.Internal},
+		{action: `{"status": 418}`, want: codes.Unknown},
+		{action: `{"grpcStatus": 14}`, want: codes.Unavailable},
+		{action: `{"grpcStatus": "NOT_FOUND", "status": 503}`, want: codes.NotFound},
+	}
+
+	for _, tt := range tests {
+
+		var action Action
+
+		if err := json.Unmarshal([]byte(tt.action), &action); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", tt.action, err)
+		}
+
+		if got := action.grpcCode(); got != tt.want {
+			t.Errorf("grpcCode(%s) = %v, want %v", tt.action, got, tt.want)
+		}
+	}
+}
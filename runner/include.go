@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// loader merges a scenario document and everything it includes into a single runner,
+// remembering which file defined what to report conflicts.
+type loader struct {
+	runner      *Runner
+	interpolate func(source []byte) ([]byte, error)
+	files       []string
+	seen        map[string]bool
+	services    map[string]string
+	scenarios   map[string]string
+	templates   map[string]string
+	experiments map[string]string
+	ports       map[int32]string
+	management  string
+	tracing     string
+}
+
+func newLoader(interpolate func(source []byte) ([]byte, error)) *loader {
+	return &loader{
+		runner:      &Runner{Service: map[string]*Service{}, Scenario: map[string]*Scenario{}},
+		interpolate: interpolate,
+		seen:        map[string]bool{},
+		services:    map[string]string{},
+		scenarios:   map[string]string{},
+		templates:   map[string]string{},
+		experiments: map[string]string{},
+		ports:       map[int32]string{},
+	}
+}
+
+// path loads a scenario file, or every `*.json` file of a directory.
+func (l *loader) path(path string) error {
+
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return errors.Wrapf(err, "Unable to resolve scenario path: %s", path)
+	}
+
+	info, err := os.Stat(abs)
+
+	if err != nil {
+		return errors.Wrap(err, "Unable to read scenario file")
+	}
+
+	if !info.IsDir() {
+		return l.file(abs)
+	}
+
+	files, err := filepath.Glob(filepath.Join(abs, "*.json"))
+
+	if err != nil {
+		return errors.Wrapf(err, "Unable to list scenario directory: %s", abs)
+	}
+
+	if len(files) == 0 {
+		return errors.Errorf("There are no scenario files in directory: %s", abs)
+	}
+
+	for _, f := range files {
+		if err := l.file(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *loader) file(abs string) error {
+
+	if l.seen[abs] {
+		return nil
+	}
+
+	l.seen[abs] = true
+
+	source, err := ioutil.ReadFile(abs)
+
+	if err != nil {
+		return errors.Wrapf(err, "Unable to read scenario file: %s", abs)
+	}
+
+	file, err := l.interpolate(StripComments(source))
+
+	if err != nil {
+		return errors.Wrapf(err, "Unable to interpolate scenario file: %s", abs)
+	}
+
+	l.files = append(l.files, abs)
+
+	return l.document(abs, filepath.Dir(abs), file)
+}
+
+// document merges a parsed document, resolving its includes relative to dir.
+func (l *loader) document(name, dir string, data []byte) error {
+
+	part := &Runner{}
+
+	err := json.Unmarshal(data, part)
+
+	if err != nil {
+		return errors.Wrapf(err, "Unable to parse scenario file: %s", name)
+	}
+
+	err = l.merge(name, part)
+
+	if err != nil {
+		return err
+	}
+
+	for _, pattern := range part.Include {
+
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+
+		if err != nil {
+			return errors.Wrapf(err, "Include [%s] of %s is not a valid pattern", pattern, name)
+		}
+
+		if len(matches) == 0 {
+			return errors.Errorf("Include [%s] of %s matches no file", pattern, name)
+		}
+
+		for _, m := range matches {
+			if err := l.path(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// finish applies the default management port and checks it against service ports, once every file is merged.
+func (l *loader) finish(defaults bool) error {
+
+	m := &l.runner.Management
+
+	if defaults && m.Port == 0 && !m.Disabled {
+		m.Port = DefaultManagementPort
+	}
+
+	if m.Port == 0 || m.Disabled {
+		return nil
+	}
+
+	other, ok := l.ports[m.Port]
+
+	if !ok {
+		return nil
+	}
+
+	if len(l.management) == 0 {
+		return errors.Errorf("Default management port [%d] is already used by %s. Set `management.port` to another port", m.Port, other)
+	}
+
+	return errors.Errorf("Port [%d] of management in %s is already used by %s", m.Port, l.management, other)
+}
+
+func (l *loader) merge(name string, part *Runner) error {
+
+	for k, v := range part.Service {
+
+		if other, ok := l.services[k]; ok {
+			return errors.Errorf("Service [%s] is defined in both %s and %s", k, other, name)
+		}
+
+		if v != nil && v.Port > 0 {
+
+			if other, ok := l.ports[v.Port]; ok {
+				return errors.Errorf("Port [%d] of service [%s] in %s is already used by %s", v.Port, k, name, other)
+			}
+
+			l.ports[v.Port] = "service [" + k + "] in " + name
+		}
+
+		l.services[k] = name
+		l.runner.Service[k] = v
+	}
+
+	for k, v := range part.Scenario {
+
+		if other, ok := l.scenarios[k]; ok {
+			return errors.Errorf("Scenario [%s] is defined in both %s and %s", k, other, name)
+		}
+
+		l.scenarios[k] = name
+		l.runner.Scenario[k] = v
+	}
+
+	for k, v := range part.Template {
+
+		if other, ok := l.templates[k]; ok {
+			return errors.Errorf("Template [%s] is defined in both %s and %s", k, other, name)
+		}
+
+		if l.runner.Template == nil {
+			l.runner.Template = map[string]*Template{}
+		}
+
+		l.templates[k] = name
+		l.runner.Template[k] = v
+	}
+
+	for k, v := range part.Experiment {
+
+		if other, ok := l.experiments[k]; ok {
+			return errors.Errorf("Experiment [%s] is defined in both %s and %s", k, other, name)
+		}
+
+		if l.runner.Experiment == nil {
+			l.runner.Experiment = map[string]*Experiment{}
+		}
+
+		l.experiments[k] = name
+		l.runner.Experiment[k] = v
+	}
+
+	if part.Management != (Management{}) {
+
+		if len(l.management) > 0 {
+			return errors.Errorf("Management is defined in both %s and %s", l.management, name)
+		}
+
+		l.management = name
+		l.runner.Management = part.Management
+	}
+
+	if part.Tracing != nil {
+
+		if len(l.tracing) > 0 {
+			return errors.Errorf("Tracing is defined in both %s and %s", l.tracing, name)
+		}
+
+		l.tracing = name
+		l.runner.Tracing = part.Tracing
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestReadIncludes(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		path     string
+		services []string
+		err      string
+	}{
+		{
+			name: "glob",
+			files: map[string]string{
+				"main.json":         `{"include": ["teams/*.json"], "service": {"gateway": {"port": 8080}}}`,
+				"teams/search.json": `{"service": {"search": {"port": 8081}}}`,
+				"teams/order.json":  `{"service": {"order": {"port": 8082}}}`,
+			},
+			path:     "main.json",
+			services: []string{"gateway", "order", "search"},
+		},
+		{
+			name: "directory",
+			files: map[string]string{
+				"search.json": `{"service": {"search": {"port": 8081}}}`,
+				"order.json":  `{"service": {"order": {"port": 8082}}}`,
+				"notes.txt":   `not a scenario`,
+			},
+			path:     ".",
+			services: []string{"order", "search"},
+		},
+		{
+			name: "included twice",
+			files: map[string]string{
+				"main.json":   `{"include": ["common.json", "*.json"]}`,
+				"common.json": `{"service": {"search": {"port": 8081}}}`,
+			},
+			path:     "main.json",
+			services: []string{"search"},
+		},
+		{
+			name: "duplicate service",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "service": {"search": {"port": 8080}}}`,
+				"other.json": `{"service": {"search": {"port": 8081}}}`,
+			},
+			path: "main.json",
+			err:  "Service [search] is defined in both",
+		},
+		{
+			name: "duplicate scenario",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "scenario": {"ok": {}}}`,
+				"other.json": `{"scenario": {"ok": {}}}`,
+			},
+			path: "main.json",
+			err:  "Scenario [ok] is defined in both",
+		},
+		{
+			name: "duplicate port",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "service": {"search": {"port": 8080}}}`,
+				"other.json": `{"service": {"order": {"port": 8080}}}`,
+			},
+			path: "main.json",
+			err:  "Port [8080] of service [order]",
+		},
+		{
+			name: "management port",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "management": {"port": 8080}}`,
+				"other.json": `{"service": {"search": {"port": 8080}}}`,
+			},
+			path: "main.json",
+			err:  "Port [8080] of management",
+		},
+		{
+			name: "default management port",
+			files: map[string]string{
+				"main.json": `{"service": {"search": {"port": 9090}}}`,
+			},
+			path: "main.json",
+			err:  "Default management port [9090] is already used by service [search]",
+		},
+		{
+			name: "disabled management",
+			files: map[string]string{
+				"main.json": `{"service": {"search": {"port": 9090}}, "management": {"disabled": true}}`,
+			},
+			path:     "main.json",
+			services: []string{"search"},
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"main.json": `{"include": ["teams/*.json"]}`,
+			},
+			path: "main.json",
+			err:  "matches no file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			dir := t.TempDir()
+
+			for name, content := range tt.files {
+
+				path := filepath.Join(dir, name)
+
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			g, err := Read(filepath.Join(dir, tt.path))
+
+			if len(tt.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Read() = %v, want error containing %q", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var services []string
+
+			for name := range g.Service {
+				services = append(services, name)
+			}
+
+			sort.Strings(services)
+
+			if strings.Join(services, ",") != strings.Join(tt.services, ",") {
+				t.Errorf("services = %v, want %v", services, tt.services)
+			}
+		})
+	}
+}
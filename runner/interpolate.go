@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// filePrefix marks a reference read from a file, e.g. `${file:/run/secrets/token}`.
+const filePrefix = "file:"
+
+// Interpolate replaces `${VAR}`, `${VAR:-default}`, `${VAR:?message}` and `${file:path}` references
+// in a scenario document. `$$` escapes a literal `$`. Values are JSON escaped inside string literals,
+// and inserted as is elsewhere, e.g. `"port": ${PORT:-8080}`.
+func Interpolate(source []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	return replaceReferences(source, func(expression string, _ bool) (string, error) {
+		return resolve(expression, lookup)
+	})
+}
+
+// replaceReferences replaces every reference of source with the value given by resolver, JSON escaped inside string literals.
+func replaceReferences(source []byte, resolver func(expression string, quoted bool) (string, error)) ([]byte, error) {
+
+	var b bytes.Buffer
+
+	quoted := false
+
+	for i := 0; i < len(source); i++ {
+
+		c := source[i]
+
+		if c == '\\' && quoted && i+1 < len(source) {
+			b.WriteByte(c)
+			b.WriteByte(source[i+1])
+			i++
+			continue
+		}
+
+		if c == '"' {
+			quoted = !quoted
+		}
+
+		if c != '$' || i+1 >= len(source) {
+			b.WriteByte(c)
+			continue
+		}
+
+		if source[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		if source[i+1] != '{' {
+			b.WriteByte(c)
+			continue
+		}
+
+		end := bytes.IndexByte(source[i+2:], '}')
+
+		if end < 0 {
+			return nil, errors.Errorf("Unterminated reference at offset %d", i)
+		}
+
+		expression := string(source[i+2 : i+2+end])
+
+		value, err := resolver(expression, quoted)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if quoted {
+			escaped, _ := json.Marshal(value)
+			value = string(escaped[1 : len(escaped)-1])
+		}
+
+		b.WriteString(value)
+
+		i += end + 2
+	}
+
+	return b.Bytes(), nil
+}
+
+// preview resolves variables from lookup and defaults only, without reading files or failing on missing values,
+// so a scenario interpolated on another machine can be inspected. Missing values are `""` in string literals
+// and `null` elsewhere.
+func preview(source []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	return replaceReferences(source, func(expression string, quoted bool) (string, error) {
+
+		name, operator, argument := split(expression)
+
+		if len(name) == 0 {
+			return "", errors.New("Empty reference ${} given")
+		}
+
+		if !strings.HasPrefix(name, filePrefix) {
+			if value, ok := lookup(name); ok && len(value) > 0 {
+				return value, nil
+			}
+		}
+
+		if operator == ":-" {
+			return argument, nil
+		}
+
+		if quoted {
+			return "", nil
+		}
+
+		return "null", nil
+	})
+}
+
+// split separates the name, the `:-` or `:?` operator and its argument of a reference.
+func split(expression string) (name, operator, argument string) {
+
+	if i := strings.Index(expression, ":-"); i >= 0 {
+		return expression[:i], ":-", expression[i+2:]
+	}
+
+	if i := strings.Index(expression, ":?"); i >= 0 {
+		return expression[:i], ":?", expression[i+2:]
+	}
+
+	return expression, "", ""
+}
+
+func resolve(expression string, lookup func(string) (string, bool)) (string, error) {
+
+	name, operator, argument := split(expression)
+
+	if len(name) == 0 {
+		return "", errors.New("Empty reference ${} given")
+	}
+
+	var value string
+	var ok bool
+
+	if strings.HasPrefix(name, filePrefix) {
+
+		f, err := ioutil.ReadFile(strings.TrimPrefix(name, filePrefix))
+
+		if err == nil {
+			value, ok = strings.TrimRight(string(f), "\r\n"), true
+		} else if !os.IsNotExist(err) || len(operator) == 0 {
+			return "", errors.Wrapf(err, "Reference ${%s} can not read", name)
+		}
+
+	} else {
+		value, ok = lookup(name)
+	}
+
+	if ok && len(value) > 0 {
+		return value, nil
+	}
+
+	switch operator {
+	case ":-":
+		return argument, nil
+	case ":?":
+		if len(argument) == 0 {
+			argument = "must be set"
+		}
+
+		return "", errors.Errorf("Reference ${%s} %s", name, argument)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+
+	secret := filepath.Join(t.TempDir(), "token")
+
+	if err := ioutil.WriteFile(secret, []byte("s3cr\"et\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{"PORT": "9090", "HOST": "payment", "EMPTY": ""}
+
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		source string
+		want   string
+		err    bool
+	}{
+		{source: `{"port": ${PORT}}`, want: `{"port": 9090}`},
+		{source: `{"host": "http://${HOST}:${PORT}"}`, want: `{"host": "http://payment:9090"}`},
+		{source: `{"port": ${MISSING:-8080}}`, want: `{"port": 8080}`},
+		{source: `{"host": "${EMPTY:-local}"}`, want: `{"host": "local"}`},
+		{source: `{"host": "${HOST:-local}"}`, want: `{"host": "payment"}`},
+		{source: `{"host": "${MISSING}"}`, want: `{"host": ""}`},
+		{source: `{"host": "${MISSING:?is required}"}`, err: true},
+		{source: `{"host": "${EMPTY:?}"}`, err: true},
+		{source: `{"token": "${file:` + secret + `}"}`, want: `{"token": "s3cr\"et"}`},
+		{source: `{"token": "${file:/does/not/exist:-none}"}`, want: `{"token": "none"}`},
+		{source: `{"token": "${file:/does/not/exist}"}`, err: true},
+		{source: `{"price": "$$5", "raw": "\${HOST}"}`, want: `{"price": "$5", "raw": "\${HOST}"}`},
+		{source: `{"host": "${}"}`, err: true},
+		{source: `"${HOST`, err: true},
+	}
+
+	for _, tt := range tests {
+
+		got, err := Interpolate([]byte(tt.source), lookup)
+
+		if tt.err {
+			if err == nil {
+				t.Errorf("Interpolate(%s) = %s, want error", tt.source, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Interpolate(%s) failed: %v", tt.source, err)
+			continue
+		}
+
+		if string(got) != tt.want {
+			t.Errorf("Interpolate(%s) = %s, want %s", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+
+	lookup := func(name string) (string, bool) {
+		if name == "PORT" {
+			return "9090", true
+		}
+
+		return "", false
+	}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: `{"port": ${PORT:?is required}}`, want: `{"port": 9090}`},
+		{source: `{"port": ${MISSING:-8080}}`, want: `{"port": 8080}`},
+		{source: `{"port": ${MISSING:?is required}}`, want: `{"port": null}`},
+		{source: `{"token": "${file:/run/secrets/token}"}`, want: `{"token": ""}`},
+		{source: `{"token": "${file:/run/secrets/token:-none}"}`, want: `{"token": "none"}`},
+	}
+
+	for _, tt := range tests {
+
+		got, err := preview([]byte(tt.source), lookup)
+
+		if err != nil {
+			t.Errorf("preview(%s) failed: %v", tt.source, err)
+			continue
+		}
+
+		if string(got) != tt.want {
+			t.Errorf("preview(%s) = %s, want %s", tt.source, got, tt.want)
+		}
+	}
+}
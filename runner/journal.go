@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultJournalSize = 1000
+
+type Entry struct {
+	Id       int64               `json:"id"`
+	Time     time.Time           `json:"time"`
+	Service  string              `json:"service"`
+	Route    string              `json:"route"`
+	Method   string              `json:"method"`
+	Path     string              `json:"path"`
+	Query    string              `json:"query"`
+	Header   map[string][]string `json:"header"`
+	Body     string              `json:"body"`
+	Subject  string              `json:"subject"`
+	Scenario string              `json:"scenario"`
+	Action   string              `json:"action"`
+	Status   int                 `json:"status"`
+	Elapsed  time.Duration       `json:"elapsed"`
+}
+
+// Query filters journal entries. Zero values match everything.
+type Query struct {
+	Service  string
+	Route    string
+	Method   string
+	Path     string
+	Scenario string
+	Action   string
+	Status   int
+	Header   map[string]string
+	Body     string
+	Subject  string
+	Since    int64
+}
+
+// Journal keeps the last received requests in a bounded ring. Once full, head is the oldest entry.
+type Journal struct {
+	entries []Entry
+	size    int
+	head    int
+	next    int64
+	sync.Mutex
+}
+
+func NewJournal(size int) *Journal {
+	if size <= 0 {
+		size = DefaultJournalSize
+	}
+
+	return &Journal{
+		entries: make([]Entry, 0, size),
+		size:    size,
+	}
+}
+
+func (j *Journal) Record(e Entry) {
+	j.Lock()
+	defer j.Unlock()
+
+	j.next++
+	e.Id = j.next
+
+	if len(j.entries) < j.size {
+		j.entries = append(j.entries, e)
+		return
+	}
+
+	j.entries[j.head] = e
+	j.head = (j.head + 1) % j.size
+}
+
+// Find returns matching entries, oldest first.
+func (j *Journal) Find(q Query) []Entry {
+	j.Lock()
+	defer j.Unlock()
+
+	result := make([]Entry, 0)
+
+	for i := range j.entries {
+
+		e := j.entries[(j.head+i)%len(j.entries)]
+
+		if q.Match(e) {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+func (j *Journal) Count(q Query) int {
+	return len(j.Find(q))
+}
+
+func (j *Journal) Clear() {
+	j.Lock()
+	defer j.Unlock()
+
+	j.entries = j.entries[:0]
+	j.head = 0
+}
+
+func (q Query) Match(e Entry) bool {
+
+	if e.Id <= q.Since {
+		return false
+	}
+
+	if len(q.Service) > 0 && q.Service != e.Service {
+		return false
+	}
+
+	if len(q.Route) > 0 && q.Route != e.Route {
+		return false
+	}
+
+	if len(q.Method) > 0 && !strings.EqualFold(q.Method, e.Method) {
+		return false
+	}
+
+	if len(q.Path) > 0 && q.Path != e.Path {
+		return false
+	}
+
+	if len(q.Scenario) > 0 && q.Scenario != e.Scenario {
+		return false
+	}
+
+	if len(q.Action) > 0 && q.Action != e.Action {
+		return false
+	}
+
+	if q.Status > 0 && q.Status != e.Status {
+		return false
+	}
+
+	for k, v := range q.Header {
+		if !contains(e.Header[http.CanonicalHeaderKey(k)], v) {
+			return false
+		}
+	}
+
+	if len(q.Body) > 0 && !strings.Contains(e.Body, q.Body) {
+		return false
+	}
+
+	if len(q.Subject) > 0 && q.Subject != e.Subject {
+		return false
+	}
+
+	return true
+}
+
+func contains(values []string, v string) bool {
+
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJournalRecord(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		size    int
+		records int
+		want    []int64
+	}{
+		{name: "empty", size: 3, records: 0, want: []int64{}},
+		{name: "not full", size: 3, records: 2, want: []int64{1, 2}},
+		{name: "full", size: 3, records: 3, want: []int64{1, 2, 3}},
+		{name: "wrapped", size: 3, records: 5, want: []int64{3, 4, 5}},
+		{name: "wrapped twice", size: 3, records: 7, want: []int64{5, 6, 7}},
+		{name: "single", size: 1, records: 4, want: []int64{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			j := NewJournal(tt.size)
+
+			for i := 0; i < tt.records; i++ {
+				j.Record(Entry{})
+			}
+
+			got := []int64{}
+
+			for _, e := range j.Find(Query{}) {
+				got = append(got, e.Id)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalClear(t *testing.T) {
+
+	j := NewJournal(2)
+
+	for i := 0; i < 3; i++ {
+		j.Record(Entry{})
+	}
+
+	j.Clear()
+	j.Record(Entry{})
+
+	if got := j.Find(Query{}); len(got) != 1 || got[0].Id != 4 {
+		t.Errorf("Find() after Clear = %v, want only entry 4", got)
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+
+	e := Entry{
+		Id:     5,
+		Method: "GET",
+		Status: 200,
+		Header: map[string][]string{"Accept": {"text/html", "application/json"}},
+		Body:   `{"id":42}`,
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  bool
+	}{
+		{name: "empty", query: Query{}, want: true},
+		{name: "method case", query: Query{Method: "get"}, want: true},
+		{name: "status", query: Query{Status: 404}, want: false},
+		{name: "since", query: Query{Since: 5}, want: false},
+		{name: "first header value", query: Query{Header: map[string]string{"accept": "text/html"}}, want: true},
+		{name: "second header value", query: Query{Header: map[string]string{"Accept": "application/json"}}, want: true},
+		{name: "missing header value", query: Query{Header: map[string]string{"Accept": "text/plain"}}, want: false},
+		{name: "body substring", query: Query{Body: `"id":42`}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Match(e); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
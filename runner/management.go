@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/fasthttp/router"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DefaultManagementPort is the management port of scenario files which do not set one.
+const DefaultManagementPort int32 = 9090
+
+// Management configures the port serving metrics and admin endpoints. It runs unless disabled,
+// on a random port when Port is 0.
+type Management struct {
+	Port     int32 `json:"port"`
+	Journal  int   `json:"journal"`
+	Disabled bool  `json:"disabled"`
+}
+
+func (g *Runner) runToManagement() (string, error) {
+
+	r := router.New()
+
+	r.PanicHandler = func(ctx *fasthttp.RequestCtx, err interface{}) {
+		g.ErrorHandler(ctx, errors.Errorf("%+v", err))
+	}
+
+	r.MethodNotAllowed = func(ctx *fasthttp.RequestCtx) {
+		g.respondError(ctx, nil, ErrorMethodNotAllowed, fasthttp.StatusMethodNotAllowed, errors.Errorf("%s %s", ctx.Method(), ctx.Path()))
+	}
+
+	r.NotFound = func(ctx *fasthttp.RequestCtx) {
+		g.respondError(ctx, nil, ErrorNotFound, fasthttp.StatusNotFound, errors.Errorf("%s %s", ctx.Method(), ctx.Path()))
+	}
+
+	r.Handle(fasthttp.MethodGet, "/metrics", g.metricsHandler())
+	r.Handle(fasthttp.MethodGet, "/journal", g.journalHandler())
+	r.Handle(fasthttp.MethodGet, "/journal/count", g.journalCountHandler())
+	r.Handle(fasthttp.MethodDelete, "/journal", g.journalClearHandler())
+	r.Handle(fasthttp.MethodGet, "/services", g.servicesHandler())
+	r.Handle(fasthttp.MethodPut, "/services/{service}/scenario", g.scenarioHandler())
+	r.Handle(fasthttp.MethodPost, "/reset", g.resetHandler())
+	r.Handle(fasthttp.MethodGet, "/toxics", g.toxicsHandler())
+	r.Handle(fasthttp.MethodPost, "/toxics/{service}/{name}/enable", g.toxicHandler(true))
+	r.Handle(fasthttp.MethodPost, "/toxics/{service}/{name}/disable", g.toxicHandler(false))
+
+	ln, err := g.listen(g.Management.Port, nil, r.Handler)
+
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info(fmt.Sprintf("[%d] Management server started", ln.Addr().(*net.TCPAddr).Port))
+
+	return ln.Addr().String(), nil
+}
+
+func (g *Runner) metricsHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBody(g.Metrics.Expose())
+		ctx.SetStatusCode(fasthttp.StatusOK)
+		ctx.SetContentType(ContentTypePrometheus)
+	}
+}
+
+func (g *Runner) journalHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		q, err := parseQuery(ctx.QueryArgs())
+
+		if err != nil {
+			g.badRequest(ctx, err)
+			return
+		}
+
+		g.writeJSON(ctx, g.Journal.Find(q))
+	}
+}
+
+func (g *Runner) journalCountHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		q, err := parseQuery(ctx.QueryArgs())
+
+		if err != nil {
+			g.badRequest(ctx, err)
+			return
+		}
+
+		g.writeJSON(ctx, map[string]int{"count": g.Journal.Count(q)})
+	}
+}
+
+func (g *Runner) journalClearHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		g.Journal.Clear()
+
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+}
+
+func (g *Runner) toxicsHandler() fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		g.writeJSON(ctx, g.Toxics())
+	}
+}
+
+func (g *Runner) toxicHandler(enabled bool) fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+		err := g.SetToxic(fmt.Sprint(ctx.UserValue("service")), fmt.Sprint(ctx.UserValue("name")), enabled)
+
+		if err != nil {
+			g.notFound(ctx, err)
+			return
+		}
+
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+}
+
+func (g *Runner) writeJSON(ctx *fasthttp.RequestCtx, v interface{}) {
+
+	body, err := json.Marshal(v)
+
+	if err != nil {
+		g.ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.SetBody(body)
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType(runtime.ContentTypeJSON)
+}
+
+func (g *Runner) badRequest(ctx *fasthttp.RequestCtx, cause error) {
+	g.respondError(ctx, nil, ErrorBadRequest, fasthttp.StatusBadRequest, cause)
+}
+
+func (g *Runner) notFound(ctx *fasthttp.RequestCtx, cause error) {
+	g.respondError(ctx, nil, ErrorNotFound, fasthttp.StatusNotFound, cause)
+}
+
+// parseQuery reads journal filters, e.g. `?service=payment&header=Idempotency-Key:abc`.
+func parseQuery(args *fasthttp.Args) (Query, error) {
+
+	q := Query{
+		Service:  string(args.Peek("service")),
+		Route:    string(args.Peek("route")),
+		Method:   string(args.Peek("method")),
+		Path:     string(args.Peek("path")),
+		Scenario: string(args.Peek("scenario")),
+		Action:   string(args.Peek("action")),
+		Body:     string(args.Peek("body")),
+		Subject:  string(args.Peek("subject")),
+		Header:   map[string]string{},
+	}
+
+	if v := args.Peek("status"); len(v) > 0 {
+		status, err := strconv.Atoi(string(v))
+
+		if err != nil {
+			return q, errors.Wrapf(err, "Status filter must be a number. Value: %s", v)
+		}
+
+		q.Status = status
+	}
+
+	if v := args.Peek("since"); len(v) > 0 {
+		since, err := strconv.ParseInt(string(v), 10, 64)
+
+		if err != nil {
+			return q, errors.Wrapf(err, "Since filter must be a number. Value: %s", v)
+		}
+
+		q.Since = since
+	}
+
+	for _, v := range args.PeekMulti("header") {
+		kv := strings.SplitN(string(v), ":", 2)
+
+		if len(kv) != 2 {
+			return q, errors.Errorf("Header filter must be in `name:value` form. Value: %s", v)
+		}
+
+		q.Header[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return q, nil
+}
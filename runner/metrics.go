@@ -0,0 +1,241 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ContentTypePrometheus = "text/plain; version=0.0.4; charset=utf-8"
+
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+type Metrics struct {
+	endpointCallCounts map[string]int
+	requests           map[requestLabels]int
+	latencies          map[routeLabels]*histogram
+	inFlight           map[string]int
+	active             map[routeLabels]string
+	sync.Mutex
+}
+
+type routeLabels struct {
+	service string
+	route   string
+}
+
+type requestLabels struct {
+	routeLabels
+	scenario string
+	action   string
+	status   int
+}
+
+type histogram struct {
+	buckets []int
+	count   int
+	sum     float64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		endpointCallCounts: map[string]int{},
+		requests:           map[requestLabels]int{},
+		latencies:          map[routeLabels]*histogram{},
+		inFlight:           map[string]int{},
+		active:             map[routeLabels]string{},
+	}
+}
+
+func (metrics *Metrics) incrementEndpointCallCount(httpMethod, route string) {
+
+	if httpMethod == "" || route == "" {
+		return
+	}
+
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	key := endpointCallCountKey(httpMethod, route)
+
+	metrics.endpointCallCounts[key]++
+}
+
+// GetEndpointCallCount returns the number of calls made to a route template, e.g. `/api/{id}`.
+func (metrics *Metrics) GetEndpointCallCount(httpMethod, route string) int {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	return metrics.endpointCallCounts[endpointCallCountKey(httpMethod, route)]
+}
+
+func endpointCallCountKey(httpMethod, route string) string {
+	return fmt.Sprintf("%s-%s", httpMethod, route)
+}
+
+func (metrics *Metrics) requestStarted(service string) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	metrics.inFlight[service]++
+}
+
+func (metrics *Metrics) requestFinished(service, route, scenario, action string, status int, elapsed time.Duration) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	metrics.inFlight[service]--
+
+	r := routeLabels{service: service, route: route}
+
+	metrics.requests[requestLabels{routeLabels: r, scenario: scenario, action: action, status: status}]++
+
+	h, ok := metrics.latencies[r]
+
+	if !ok {
+		h = &histogram{buckets: make([]int, len(latencyBuckets))}
+		metrics.latencies[r] = h
+	}
+
+	seconds := elapsed.Seconds()
+
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			h.buckets[i]++
+		}
+	}
+
+	h.count++
+	h.sum += seconds
+}
+
+func (metrics *Metrics) setActiveScenario(service, route, scenario string) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	metrics.active[routeLabels{service: service, route: route}] = scenario
+}
+
+// Expose writes every metric in the Prometheus text exposition format.
+func (metrics *Metrics) Expose() []byte {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	var b bytes.Buffer
+
+	b.WriteString("# HELP gaos_requests_total Total number of requests handled by mocked routes.\n")
+	b.WriteString("# TYPE gaos_requests_total counter\n")
+
+	requests := make([]requestLabels, 0, len(metrics.requests))
+
+	for k := range metrics.requests {
+		requests = append(requests, k)
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].String() < requests[j].String()
+	})
+
+	for _, k := range requests {
+		fmt.Fprintf(&b, "gaos_requests_total{%s} %d\n", k, metrics.requests[k])
+	}
+
+	b.WriteString("# HELP gaos_request_duration_seconds Time spent handling requests, including injected latency.\n")
+	b.WriteString("# TYPE gaos_request_duration_seconds histogram\n")
+
+	routes := make([]routeLabels, 0, len(metrics.latencies))
+
+	for k := range metrics.latencies {
+		routes = append(routes, k)
+	}
+
+	sortRoutes(routes)
+
+	for _, k := range routes {
+		h := metrics.latencies[k]
+
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(&b, "gaos_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", k, strconv.FormatFloat(le, 'g', -1, 64), h.buckets[i])
+		}
+
+		fmt.Fprintf(&b, "gaos_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", k, h.count)
+		fmt.Fprintf(&b, "gaos_request_duration_seconds_sum{%s} %s\n", k, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "gaos_request_duration_seconds_count{%s} %d\n", k, h.count)
+	}
+
+	b.WriteString("# HELP gaos_requests_in_flight Number of requests currently being handled.\n")
+	b.WriteString("# TYPE gaos_requests_in_flight gauge\n")
+
+	services := make([]string, 0, len(metrics.inFlight))
+
+	for k := range metrics.inFlight {
+		services = append(services, k)
+	}
+
+	sort.Strings(services)
+
+	for _, k := range services {
+		fmt.Fprintf(&b, "gaos_requests_in_flight{service=\"%s\"} %d\n", escapeLabel(k), metrics.inFlight[k])
+	}
+
+	b.WriteString("# HELP gaos_active_scenario Scenario currently handling requests of a route.\n")
+	b.WriteString("# TYPE gaos_active_scenario gauge\n")
+
+	routes = routes[:0]
+
+	for k := range metrics.active {
+		routes = append(routes, k)
+	}
+
+	sortRoutes(routes)
+
+	for _, k := range routes {
+		fmt.Fprintf(&b, "gaos_active_scenario{%s,scenario=\"%s\"} 1\n", k, escapeLabel(metrics.active[k]))
+	}
+
+	return b.Bytes()
+}
+
+func (r routeLabels) String() string {
+	return fmt.Sprintf("service=\"%s\",route=\"%s\"", escapeLabel(r.service), escapeLabel(r.route))
+}
+
+func (r requestLabels) String() string {
+	return fmt.Sprintf("%s,scenario=\"%s\",action=\"%s\",status=\"%d\"", r.routeLabels, escapeLabel(r.scenario), escapeLabel(r.action), r.status)
+}
+
+func sortRoutes(routes []routeLabels) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].service != routes[j].service {
+			return routes[i].service < routes[j].service
+		}
+
+		return routes[i].route < routes[j].route
+	})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
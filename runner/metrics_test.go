@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeLabel(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "/api/{id}", want: "/api/{id}"},
+		{value: `say "hi"`, want: `say \"hi\"`},
+		{value: `C:\path`, want: `C:\\path`},
+		{value: "two\nlines", want: `two\nlines`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLabel(tt.value); got != tt.want {
+			t.Errorf("escapeLabel(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLabels(t *testing.T) {
+
+	tests := []struct {
+		labels requestLabels
+		want   string
+	}{
+		{
+			labels: requestLabels{routeLabels: routeLabels{service: "search", route: "/api/{id}"}, scenario: "latency", action: ActionAccept, status: 200},
+			want:   `service="search",route="/api/{id}",scenario="latency",action="accept",status="200"`,
+		},
+		{
+			labels: requestLabels{routeLabels: routeLabels{service: `a"b`, route: "/"}, scenario: "x", action: ActionIgnore, status: 503},
+			want:   `service="a\"b",route="/",scenario="x",action="ignore",status="503"`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.labels.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestExpose(t *testing.T) {
+
+	m := NewMetrics()
+
+	m.requestStarted("search")
+	m.requestFinished("search", "/api/{id}", "latency", ActionAccept, 200, 30*time.Millisecond)
+	m.setActiveScenario("search", "/api/{id}", "latency")
+
+	body := string(m.Expose())
+
+	for _, want := range []string{
+		`gaos_requests_total{service="search",route="/api/{id}",scenario="latency",action="accept",status="200"} 1`,
+		`gaos_request_duration_seconds_bucket{service="search",route="/api/{id}",le="0.025"} 0`,
+		`gaos_request_duration_seconds_bucket{service="search",route="/api/{id}",le="0.05"} 1`,
+		`gaos_request_duration_seconds_count{service="search",route="/api/{id}"} 1`,
+		`gaos_requests_in_flight{service="search"} 0`,
+		`gaos_active_scenario{service="search",route="/api/{id}",scenario="latency"} 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("Expose() does not contain %s", want)
+		}
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package runner
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"github.com/Trendyol/gaos/logger"
@@ -24,8 +26,14 @@ import (
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"io/ioutil"
 	"k8s.io/apimachinery/pkg/runtime"
+	"net"
 	"os"
 	"os/signal"
 	"sync"
@@ -45,37 +53,88 @@ const BANNER = `
 ╚════════════════════════════════════ Trendyol Tech ═══════════════════════════════════╝
                                                                                (v%s)
 `
-const VERSION = "0.1.0"
+
+// VERSION is the release of this build. `gaos start` builds service images on `trendyol/gaos:VERSION`,
+// so it must be bumped with every release for containers to understand the scenario files.
+const VERSION = "0.2.0"
 
 const (
-	ResultTypeStatic   = "static"
-	ResultTypeFile     = "file"
-	ResultTypeRedirect = "redirect"
+	ResultTypeStatic    = "static"
+	ResultTypeFile      = "file"
+	ResultTypeRedirect  = "redirect"
+	ResultTypeWebSocket = "websocket"
+	ResultTypeSSE       = "sse"
 )
 
 const (
 	FileResultTypeJson = "json"
 )
 
-type Executable func() (Done, error)
+type Executable func(r *Request) (Done, error)
+
+// Request holds what executables can match on.
+type Request struct {
+	Subject string
+	ctx     context.Context
+}
 
 type Done <-chan bool
 
+const (
+	ServiceTypeHTTP = "http"
+	ServiceTypeGRPC = "grpc"
+	ServiceTypeTCP  = "tcp"
+)
+
+const (
+	ActionAccept = "accept"
+	ActionIgnore = "ignore"
+)
+
 type Runner struct {
-	Service  map[string]*Service  `json:"service"`
-	Scenario map[string]*Scenario `json:"scenario"`
-	servers  []*fasthttp.Server
-	Metrics Metrics
+	Service    map[string]*Service    `json:"service"`
+	Scenario   map[string]*Scenario   `json:"scenario"`
+	Template   map[string]*Template   `json:"template,omitempty"`
+	Experiment map[string]*Experiment `json:"experiment,omitempty"`
+	Management Management             `json:"management"`
+	Tracing    *Tracing               `json:"tracing"`
+	Include    []string               `json:"include,omitempty"`
+	servers    []server
+	management string
+	addresses  map[string]string
+	methods    map[string]map[string]*Method
+	proxies    map[string]*tcpProxy
+	closers    []io.Closer
+	source     []byte
+	files      []string
+	ca         *authority
+	clock      Clock
+	tracer     trace.Tracer
+	stop       chan bool
+	mutex      sync.Mutex
+	Metrics    *Metrics `json:"-"`
+	Journal    *Journal `json:"-"`
 }
 
-type Metrics struct {
-	endpointCallCounts map[string]int
-	sync.Mutex
+// server is a running listener of any protocol, stopped by Shutdown.
+type server struct {
+	name     string
+	kind     string
+	listener net.Listener
+	shutdown func() error
 }
 
 type Service struct {
-	Port int32           `json:"port"`
-	Path map[string]Path `json:"path"`
+	Type       string            `json:"type"`
+	Port       int32             `json:"port"`
+	Descriptor string            `json:"descriptor"`
+	Upstream   string            `json:"upstream"`
+	Toxics     map[string]*Toxic `json:"toxics"`
+	TLS        *TLS              `json:"tls"`
+	Access     *AccessLog        `json:"access"`
+	Errors     *Errors           `json:"errors"`
+	Fallback   *Fallback         `json:"fallback"`
+	Path       map[string]Path   `json:"path"`
 }
 
 type Path struct {
@@ -84,10 +143,13 @@ type Path struct {
 }
 
 type Action struct {
-	scenario *Scenario
-	Direct   string `json:"direct"`
-	Status   int    `json:"status"`
-	Result   Result `json:"result"`
+	scenario    *Scenario
+	name        string
+	passthrough bool
+	Direct      string    `json:"direct"`
+	Status      int       `json:"status"`
+	GRPCStatus  *GRPCCode `json:"grpcStatus,omitempty"`
+	Result      Result    `json:"result"`
 }
 
 type Result struct {
@@ -106,52 +168,204 @@ type RedirectResult struct {
 
 type Scenario struct {
 	executables []Executable
-	Name        string `json:"name"`
-	Duration    string `json:"duration"`
-	Latency     string `json:"latency"`
-	Status      int    `json:"status"`
-	Rate        int    `json:"rate"`
-	Random      int    `json:"random"`
-	Limit       int    `json:"limit"`
-	Start       string `json:"start"`
-	End         string `json:"end"`
-	Accept      Action `json:"accept"`
-	Ignore      Action `json:"ignore"`
+	names       []string
+	resets      []func()
+	key         string
+	raw         json.RawMessage
+	Name        string                 `json:"name"`
+	Duration    string                 `json:"duration"`
+	Latency     string                 `json:"latency"`
+	Status      int                    `json:"status"`
+	Rate        int                    `json:"rate"`
+	Random      int                    `json:"random"`
+	Limit       int                    `json:"limit"`
+	Start       string                 `json:"start"`
+	End         string                 `json:"end"`
+	Subject     string                 `json:"subject"`
+	Extends     string                 `json:"extends,omitempty"`
+	Template    string                 `json:"template,omitempty"`
+	Args        map[string]interface{} `json:"args,omitempty"`
+	Accept      Action                 `json:"accept"`
+	Ignore      Action                 `json:"ignore"`
 }
 
 type Method struct {
 	Scenario
-	runner *Runner
+	runner   *Runner
+	service  string
+	path     string
+	access   *accessLogger
+	accepted int
+	ignored  int
+	sync.Mutex
 }
 
+// New reads the scenario file, or every `*.json` file of the directory, at path with everything they include.
+// Environment variables and file references are interpolated before parsing.
 func New(path string) (*Runner, error) {
-	runner := &Runner{}
-	runner.Metrics.endpointCallCounts = map[string]int{}
 
-	file, err := ioutil.ReadFile(path)
+	runner, err := Read(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if logger.Decorated() {
+		clr := color.New(color.FgMagenta)
+
+		_, _ = clr.Println(fmt.Sprintf(BANNER, VERSION))
+	}
+
+	return runner, nil
+}
+
+// Read loads scenarios like New without printing the banner, for commands writing to stdout.
+func Read(path string) (*Runner, error) {
+	return read(path, func(source []byte) ([]byte, error) {
+		return Interpolate(source, os.LookupEnv)
+	})
+}
+
+// Discover loads scenarios to find their services and files when they are interpolated on another machine,
+// e.g. by `gaos run` in a container. Variables come from lookup or their defaults, files are never read
+// and missing values do not fail.
+func Discover(path string, lookup func(string) (string, bool)) (*Runner, error) {
+	return read(path, func(source []byte) ([]byte, error) {
+		return preview(source, lookup)
+	})
+}
+
+func read(path string, interpolate func(source []byte) ([]byte, error)) (*Runner, error) {
+
+	l := newLoader(interpolate)
+
+	err := l.path(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.finish(true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	runner := l.runner
+	runner.files = l.files
+
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		runner.source, _ = ioutil.ReadFile(path)
+	}
+
+	runner.initialize()
+
+	return runner, nil
+}
+
+// Load parses a scenario document without printing the banner, to embed Gaos into Go programs and tests.
+// It is interpolated like files, and its includes are resolved relative to the working directory.
+func Load(r io.Reader) (*Runner, error) {
+
+	source, err := ioutil.ReadAll(r)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to read scenario file")
 	}
 
-	err = json.Unmarshal(file, runner)
+	l := newLoader(func(source []byte) ([]byte, error) {
+		return Interpolate(source, os.LookupEnv)
+	})
+
+	file, err := l.interpolate(StripComments(source))
 
 	if err != nil {
-		return nil, errors.Wrap(err, "Unable to parse scenario file")
+		return nil, errors.Wrap(err, "Unable to interpolate scenario document")
 	}
 
-	clr := color.New(color.FgMagenta)
+	err = l.document("scenario document", ".", file)
 
-	_, _ = clr.Println(fmt.Sprintf(BANNER, VERSION))
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.finish(false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	runner := l.runner
+	runner.files = l.files
+
+	runner.initialize()
 
 	return runner, nil
 }
 
+// NewRunner builds a runner from Go structs instead of a scenario document.
+func NewRunner(service map[string]*Service, scenario map[string]*Scenario) *Runner {
+
+	runner := &Runner{
+		Service:  service,
+		Scenario: scenario,
+	}
+
+	runner.initialize()
+
+	return runner
+}
+
+func (g *Runner) initialize() {
+
+	if g.Metrics == nil {
+		g.Metrics = NewMetrics()
+	}
+
+	if g.Journal == nil {
+		g.Journal = NewJournal(g.Management.Journal)
+	}
+}
+
+// Run starts the given services, or all of them, and blocks until SIGINT or SIGTERM.
 func (g *Runner) Run(services ...string) {
 
-	g.resolveScenarios()
+	_, err := g.Start(context.Background(), services...)
+
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	logger.Info("Servers are stopping...")
+
+	_ = g.Shutdown()
+}
+
+// Start binds the given services, or all of them, and the management port unless it is disabled.
+// Servers run until ctx is done or Shutdown is called. It returns the bound address of each service.
+func (g *Runner) Start(ctx context.Context, services ...string) (map[string]string, error) {
+
+	g.initialize()
 
-	result := true
+	err := g.resolveScenarios()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tracer, err := g.tracing()
+
+	if err != nil {
+		return nil, err
+	}
+
+	g.tracer = tracer
+
+	addresses := map[string]string{}
 
 	for name, service := range g.Service {
 
@@ -170,130 +384,401 @@ func (g *Runner) Run(services ...string) {
 			}
 		}
 
-		result = result && g.runToService(service, name)
+		var addr string
+		var err error
+
+		switch service.Type {
+		case "", ServiceTypeHTTP:
+			addr, err = g.runToService(service, name)
+		case ServiceTypeGRPC:
+			addr, err = g.runToGRPCService(service, name)
+		case ServiceTypeTCP:
+			addr, err = g.runToTCPService(service, name)
+		default:
+			err = errors.Errorf("Unexpected service type given: %s. Available: '%s', '%s', '%s'", service.Type, ServiceTypeHTTP, ServiceTypeGRPC, ServiceTypeTCP)
+		}
+
+		if err != nil {
+			_ = g.Shutdown()
+			return nil, errors.Wrapf(err, "[%s] Server can not started", name)
+		}
+
+		addresses[name] = addr
 	}
 
 	if len(g.servers) == 0 {
-		logger.Fatal("There are no servers to run")
+		return nil, errors.New("There are no servers to run")
 	}
 
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	<-sig
+	g.mutex.Lock()
+	g.addresses = addresses
+	g.mutex.Unlock()
+
+	if !g.Management.Disabled {
+
+		addr, err := g.runToManagement()
 
-	if !result {
-		logger.Fatal("Something went wrong while running services")
+		if err != nil {
+			_ = g.Shutdown()
+			return nil, errors.Wrap(err, "Management server can not started")
+		}
+
+		g.management = addr
 	}
 
-	logger.Info("Servers are stopping...")
+	stop := make(chan bool)
+	g.stop = stop
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = g.Shutdown()
+		case <-stop:
+		}
+	}()
+
+	return addresses, nil
+}
+
+// Source returns the scenario file as read by New, before interpolation. It is nil for other runners.
+func (g *Runner) Source() []byte {
+	return g.source
+}
+
+// Files returns the absolute paths of every scenario file the runner was loaded from, in load order.
+func (g *Runner) Files() []string {
+	return g.files
+}
+
+// ManagementAddress returns the bound address of the management server, if it is running.
+func (g *Runner) ManagementAddress() string {
+	return g.management
+}
+
+// Shutdown gracefully stops every running server.
+func (g *Runner) Shutdown() error {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var result error
 
 	for _, v := range g.servers {
-		err := v.Shutdown()
+		err := v.shutdown()
+
+		_ = v.listener.Close()
 
 		if err != nil {
-			logger.Error(fmt.Sprintf("[%s] Http server can not closed, %s", v.Name, err))
+			logger.Error(fmt.Sprintf("[%s] %s server can not closed, %s", v.name, v.kind, err))
+
+			if result == nil {
+				result = err
+			}
+
 			continue
 		}
 
-		logger.Info(fmt.Sprintf("[%s] Http server closed", v.Name))
+		logger.Info(fmt.Sprintf("[%s] %s server closed", v.name, v.kind))
+	}
+
+	for _, c := range g.closers {
+		_ = c.Close()
 	}
+
+	g.servers = nil
+	g.methods = nil
+	g.proxies = nil
+	g.closers = nil
+
+	if g.stop != nil {
+		close(g.stop)
+		g.stop = nil
+	}
+
+	return result
 }
 
-func (g *Runner) runToService(service *Service, name string) bool {
+func (g *Runner) runToService(service *Service, name string) (string, error) {
 
 	r := router.New()
 
 	r.PanicHandler = func(ctx *fasthttp.RequestCtx, err interface{}) {
-		g.ErrorHandler(ctx, errors.Errorf("%+v", err))
+		g.respondError(ctx, service.Errors, ErrorInternal, fasthttp.StatusInternalServerError, errors.Errorf("%+v", err))
 	}
 
 	r.MethodNotAllowed = func(ctx *fasthttp.RequestCtx) {
-		g.ErrorHandler(ctx, errors.New("Method not allowed"))
+		g.respondError(ctx, service.Errors, ErrorMethodNotAllowed, fasthttp.StatusMethodNotAllowed, errors.Errorf("%s %s", ctx.Method(), ctx.Path()))
 	}
 
 	r.NotFound = func(ctx *fasthttp.RequestCtx) {
-		g.ErrorHandler(ctx, errors.New("Not found"))
+		g.respondError(ctx, service.Errors, ErrorNotFound, fasthttp.StatusNotFound, errors.Errorf("%s %s", ctx.Method(), ctx.Path()))
+	}
+
+	var access *accessLogger
+
+	if service.Access != nil {
+
+		a, closer, err := newAccessLogger(service.Access)
+
+		if err != nil {
+			return "", err
+		}
+
+		if closer != nil {
+			g.mutex.Lock()
+			g.closers = append(g.closers, closer)
+			g.mutex.Unlock()
+		}
+
+		access = a
+	}
+
+	if service.Fallback != nil {
+
+		fallback, err := g.fallback(service, name, access)
+
+		if err != nil {
+			return "", err
+		}
+
+		handler := fallback.Handler()
+
+		r.NotFound = handler
+		r.MethodNotAllowed = handler
 	}
 
+	methods := map[string]*Method{}
+
 	for path, value := range service.Path {
 
 		if scenario, ok := g.Scenario[value.Scenario]; ok {
 
-			method := Method{*scenario, g}
+			method := &Method{Scenario: *scenario, runner: g, service: name, path: path, access: access}
+
+			methods[path] = method
+
+			g.Metrics.setActiveScenario(name, path, scenario.key)
 
 			r.Handle(value.Method, path, method.Handler())
 
 		}
 	}
-	r.Handle(fasthttp.MethodGet, "/metrics", g.metricsHandler())
 
-	server := &fasthttp.Server{
-		Name:    fmt.Sprint(service.Port),
-		Handler: r.Handler,
-		ErrorHandler: func(ctx *fasthttp.RequestCtx, err error) {
-			g.ErrorHandler(ctx, err)
-		},
+	var config *tls.Config
+
+	if service.TLS != nil {
+
+		c, err := g.tlsConfig(service.TLS)
+
+		if err != nil {
+			return "", err
+		}
+
+		config = c
 	}
 
-	result := true
+	ln, err := g.listen(service.Port, config, r.Handler)
 
-	go func() {
+	if err != nil {
+		return "", err
+	}
+
+	g.register(name, methods)
+
+	if config != nil {
+		logger.Info(fmt.Sprintf("[%d] HTTPS server started for [%s]", ln.Addr().(*net.TCPAddr).Port, name))
+	} else {
+		logger.Info(fmt.Sprintf("[%d] HTTP server started for [%s]", ln.Addr().(*net.TCPAddr).Port, name))
+	}
+
+	return ln.Addr().String(), nil
+}
 
-		logger.Info(fmt.Sprintf("[%d] HTTP server started for [%s]", service.Port, name))
+func (g *Runner) listen(port int32, config *tls.Config, handler fasthttp.RequestHandler) (net.Listener, error) {
+
+	ln, err := net.Listen("tcp4", fmt.Sprintf(":%d", port))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config != nil {
+		ln = tls.NewListener(ln, config)
+	}
+
+	s := &fasthttp.Server{
+		Name:    fmt.Sprint(ln.Addr().(*net.TCPAddr).Port),
+		Handler: handler,
+		ErrorHandler: func(ctx *fasthttp.RequestCtx, err error) {
+			g.respondError(ctx, nil, ErrorBadRequest, fasthttp.StatusBadRequest, err)
+		},
+	}
 
-		err := server.ListenAndServe(fmt.Sprintf(":%d", service.Port))
+	go func() {
+		err := s.Serve(ln)
 
 		if err != nil {
 			logger.Error(err)
 		}
-
-		result = result && (err == nil)
 	}()
 
-	g.servers = append(g.servers, server)
+	g.track(server{name: s.Name, kind: "Http", listener: ln, shutdown: s.Shutdown})
 
-	return result
+	return ln, nil
+}
+
+func (g *Runner) register(service string, methods map[string]*Method) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.methods == nil {
+		g.methods = map[string]map[string]*Method{}
+	}
+
+	g.methods[service] = methods
+}
+
+func (g *Runner) track(s server) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.servers = append(g.servers, s)
+}
+
+// SetScenario switches the scenario handling next requests of a running route.
+func (g *Runner) SetScenario(service, path, scenario string) error {
+
+	method, err := g.method(service, path)
+
+	if err != nil {
+		return err
+	}
+
+	v, ok := g.Scenario[scenario]
+
+	if !ok {
+		return errors.Errorf("Scenario [%s] is not defined", scenario)
+	}
+
+	method.Lock()
+	method.Scenario = *v
+	method.Unlock()
+
+	g.Metrics.setActiveScenario(service, path, scenario)
+
+	logger.Info(fmt.Sprintf("[%s] Scenario of %s switched to [%s]", service, path, scenario))
+
+	return nil
+}
+
+// ActiveScenario returns the scenario handling next requests of a running route.
+func (g *Runner) ActiveScenario(service, path string) (string, error) {
+
+	method, err := g.method(service, path)
+
+	if err != nil {
+		return "", err
+	}
+
+	method.Lock()
+	defer method.Unlock()
+
+	return method.key, nil
+}
+
+func (g *Runner) method(service, path string) (*Method, error) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	methods, ok := g.methods[service]
+
+	if !ok {
+		return nil, errors.Errorf("Service [%s] is not running", service)
+	}
+
+	method, ok := methods[path]
+
+	if !ok {
+		return nil, errors.Errorf("Path [%s] is not defined for service [%s]", path, service)
+	}
+
+	return method, nil
 }
 
-func (g *Runner) resolveScenarios() {
+func (g *Runner) resolveScenarios() error {
+
+	err := g.expandScenarios()
+
+	if err != nil {
+		return err
+	}
+
+	var clock Clock = wallClock{}
+
+	if g.clock != nil {
+		clock = g.clock
+	}
 
 	for k := range g.Scenario {
 
 		scenario := g.Scenario[k]
 
+		scenario.key = k
+		scenario.Accept.name = ActionAccept
+		scenario.Ignore.name = ActionIgnore
+
+		if len(scenario.Subject) > 0 {
+
+			subject, err := NewSubject(*scenario)
+
+			if err != nil {
+				return errors.Wrapf(err, "Scenario [%s] can not resolved", k)
+			}
+
+			scenario.use("subject", subject.Execute)
+		}
+
 		if len(scenario.Start) > 0 || len(scenario.End) > 0 {
 
 			span := NewSpan(*scenario)
+			span.clock = clock
 
-			scenario.executables = append(scenario.executables, span.Execute)
+			scenario.use("span", span.Execute)
 		}
 
 		if len(scenario.Duration) > 0 {
 
 			duration := NewDuration(*scenario)
+			duration.clock = clock
 
-			scenario.executables = append(scenario.executables, duration.Execute)
+			scenario.use("duration", duration.Execute)
 		}
 
 		if len(scenario.Latency) > 0 {
 
 			latency := NewLatency(*scenario)
+			latency.clock = clock
 
-			scenario.executables = append(scenario.executables, latency.Execute)
+			scenario.use("latency", latency.Execute)
 		}
 
 		if scenario.Limit > 0 {
 
 			limit := NewLimit(*scenario)
 
-			scenario.executables = append(scenario.executables, limit.Execute)
+			scenario.use("limit", limit.Execute)
+			scenario.resets = append(scenario.resets, limit.reset)
 		}
 
 		if scenario.Rate > 0 {
 
 			rate := NewRate(*scenario)
 
-			scenario.executables = append(scenario.executables, rate.Execute)
+			scenario.use("rate", rate.Execute)
+			scenario.resets = append(scenario.resets, rate.reset)
 		}
 
 		if len(scenario.Accept.Direct) > 0 {
@@ -308,50 +793,82 @@ func (g *Runner) resolveScenarios() {
 			}
 		}
 	}
+
+	return nil
 }
 
+// ErrorHandler writes a 500 response marked with the `X-Gaos-Error` header.
 func (g *Runner) ErrorHandler(ctx *fasthttp.RequestCtx, cause error) {
+	g.respondError(ctx, nil, ErrorInternal, fasthttp.StatusInternalServerError, cause)
+}
 
-	e := WrapGaosError(cause, "Occurred http error")
+func (m *Method) Handler() fasthttp.RequestHandler {
 
-	body, _ := json.Marshal(e)
+	cnt := 0
 
-	ctx.SetBody(body)
-	ctx.SetContentType(runtime.ContentTypeJSON)
-	ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-}
+	return func(ctx *fasthttp.RequestCtx) {
 
-func (g *Runner) metricsHandler() fasthttp.RequestHandler {
+		start := time.Now()
 
-	return func(ctx *fasthttp.RequestCtx) {
-		resp, err := json.Marshal(g.Metrics.endpointCallCounts)
-
-		if err == nil {
-			ctx.SetBody(resp)
-			ctx.SetStatusCode(fasthttp.StatusOK)
-			ctx.SetContentType(runtime.ContentTypeJSON)
-		} else {
-			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		m.runner.Metrics.requestStarted(m.service)
+
+		parent := propagator.Extract(context.Background(), headerCarrier{header: &ctx.Request.Header})
+
+		spanCtx, span := m.runner.tracer.Start(parent, fmt.Sprintf("%s %s", ctx.Method(), m.path),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(string(ctx.Method())),
+				semconv.HTTPRoute(m.path),
+				attribute.String("gaos.service", m.service),
+			),
+		)
+
+		ctx.SetUserValue(traceContextKey, spanCtx)
+
+		request := &Request{}
+
+		if state := ctx.TLSConnectionState(); state != nil && len(state.PeerCertificates) > 0 {
+			request.Subject = state.PeerCertificates[0].Subject.String()
 		}
-	}
-}
 
-func (m *Method) Handler() fasthttp.RequestHandler {
+		matchCtx, match := m.runner.tracer.Start(spanCtx, "gaos.match")
 
-	cnt := 0
+		request.ctx = matchCtx
 
-	return func(ctx *fasthttp.RequestCtx) {
+		scenario, action, done := m.Execute(request)
 
-		start := time.Now()
+		match.SetAttributes(
+			attribute.String("gaos.scenario", scenario.key),
+			attribute.String("gaos.action", action.name),
+		)
+		match.End()
+
+		span.SetAttributes(
+			attribute.String("gaos.scenario", scenario.key),
+			attribute.String("gaos.action", action.name),
+		)
 
 		defer func(name string) {
-			elapsed := time.Since(start) / time.Millisecond
-			logger.Info(fmt.Sprintf("[%d] Host: %s | Path: %s | Executed: %s | Elapsed time: %dms", cnt, string(ctx.Host()), string(ctx.Request.URI().Path()), name, elapsed))
+			elapsed := time.Since(start)
+			endSpan(span, ctx.Response.StatusCode())
+			logger.DebugWithFields(fmt.Sprintf("[%d] Host: %s | Path: %s | Executed: %s | Elapsed time: %dms", cnt, string(ctx.Host()), string(ctx.Request.URI().Path()), name, elapsed/time.Millisecond), logger.Fields{
+				"service":  m.service,
+				"route":    m.path,
+				"path":     string(ctx.Path()),
+				"scenario": scenario.key,
+				"action":   action.name,
+				"status":   ctx.Response.StatusCode(),
+				"elapsed":  elapsed / time.Millisecond,
+			})
 			cnt++
-			go m.runner.Metrics.incrementEndpointCallCount(string(ctx.Request.Header.Method()), string(ctx.Request.URI().Path()))
-		}(m.Name)
+			m.runner.Metrics.incrementEndpointCallCount(string(ctx.Request.Header.Method()), m.path)
+			m.runner.Metrics.requestFinished(m.service, m.path, scenario.key, action.name, ctx.Response.StatusCode(), elapsed)
+			m.record(ctx, request, scenario, action, start, elapsed)
 
-		action, done := m.Execute()
+			if m.access != nil {
+				m.access.write(ctx, m.service, m.path, scenario, action, start, elapsed)
+			}
+		}(scenario.Name)
 
 		err := action.Execute(ctx)
 
@@ -361,45 +878,111 @@ func (m *Method) Handler() fasthttp.RequestHandler {
 
 		if err != nil {
 
-			result := WrapGaosError(err, "Occurred a error")
+			var e *Errors
 
-			body, _ := json.Marshal(result)
+			if service, ok := m.runner.Service[m.service]; ok {
+				e = service.Errors
+			}
 
-			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-			ctx.SetContentType(runtime.ContentTypeJSON)
-			ctx.SetBody(body)
+			if err == errUpgradeRequired {
+				m.runner.respondError(ctx, nil, ErrorBadRequest, fasthttp.StatusUpgradeRequired, err)
+				ctx.Response.Header.Set(fasthttp.HeaderUpgrade, "websocket")
+				return
+			}
 
+			m.runner.respondError(ctx, e, ErrorInternal, fasthttp.StatusInternalServerError, err)
 		}
 	}
 }
 
-func (m *Method) Execute() (Action, []Done) {
+func (m *Method) record(ctx *fasthttp.RequestCtx, request *Request, scenario Scenario, action Action, start time.Time, elapsed time.Duration) {
+
+	header := map[string][]string{}
+
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		header[string(key)] = append(header[string(key)], string(value))
+	})
+
+	m.runner.Journal.Record(Entry{
+		Time:     start,
+		Service:  m.service,
+		Route:    m.path,
+		Method:   string(ctx.Method()),
+		Path:     string(ctx.Path()),
+		Query:    string(ctx.QueryArgs().QueryString()),
+		Header:   header,
+		Body:     string(ctx.PostBody()),
+		Subject:  request.Subject,
+		Scenario: scenario.key,
+		Action:   action.name,
+		Status:   ctx.Response.StatusCode(),
+		Elapsed:  elapsed,
+	})
+}
+
+func (m *Method) Execute(r *Request) (Scenario, Action, []Done) {
+
+	scenario, action, done, t := m.execute(r)
+
+	if t != nil {
+		logger.Error(t.err)
+	}
+
+	return scenario, action, done
+}
+
+// trigger is the executable which made a request run `ignore`.
+type trigger struct {
+	index int
+	err   error
+}
+
+func (m *Method) execute(r *Request) (Scenario, Action, []Done, *trigger) {
 
 	var done []Done
+	var t *trigger
+
+	m.Lock()
+	scenario := m.Scenario
+	m.Unlock()
 
-	e := m.executables
-	action := m.Accept
+	e := scenario.executables
+	action := scenario.Accept
 
-	for _, method := range e {
+	for i, method := range e {
 
-		d, err := method()
+		d, err := method(r)
 
 		if d != nil {
 			done = append(done, d)
 		}
 
 		if err != nil {
-			action = m.Ignore
-			logger.Error(err)
+			action = scenario.Ignore
+			t = &trigger{index: i, err: err}
 			break
 		}
 	}
 
+	m.Lock()
+
+	if t != nil {
+		m.ignored++
+	} else {
+		m.accepted++
+	}
+
+	m.Unlock()
+
 	if action.scenario != nil {
+		m.Lock()
 		m.Scenario = *action.scenario
+		m.Unlock()
+
+		m.runner.Metrics.setActiveScenario(m.service, m.path, action.scenario.key)
 	}
 
-	return action, done
+	return scenario, action, done, t
 
 }
 
@@ -445,14 +1028,43 @@ func (a *Action) Execute(ctx *fasthttp.RequestCtx) error {
 
 			ctx.Request.CopyTo(req)
 
-			req.SetRequestURI(r.Host + string(ctx.Path()))
+			// Fallback traffic keeps its query string, explicit redirects forward only the path.
+			uri := string(ctx.Path())
+
+			if a.passthrough {
+				uri = string(ctx.RequestURI())
+			}
+
+			req.SetRequestURI(r.Host + uri)
+
+			parent := traceContext(ctx)
+
+			spanCtx, span := trace.SpanFromContext(parent).TracerProvider().Tracer(tracerName).Start(parent, "gaos.redirect",
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.HTTPMethod(string(req.Header.Method())),
+					semconv.HTTPURL(req.URI().String()),
+				),
+			)
+
+			propagator.Inject(spanCtx, headerCarrier{header: &req.Header})
+
+			start := time.Now()
 
 			err := fasthttp.Do(req, res)
 
+			ctx.SetUserValue(upstreamTimeKey, time.Since(start))
+
 			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				span.End()
+
 				return errors.Wrap(err, "HTTP request can not send")
 			}
 
+			endSpan(span, res.StatusCode())
+
 			res.CopyTo(&ctx.Response)
 
 			return nil
@@ -461,6 +1073,14 @@ func (a *Action) Execute(ctx *fasthttp.RequestCtx) error {
 
 		return nil
 
+	} else if a.Result.Type == ResultTypeWebSocket {
+
+		return a.serveWebSocket(ctx)
+
+	} else if a.Result.Type == ResultTypeSSE {
+
+		return a.serveSSE(ctx)
+
 	} else if a.Result.Type == ResultTypeStatic {
 
 		body, err := json.Marshal(a.Result.Content)
@@ -482,28 +1102,42 @@ func (a *Action) Execute(ctx *fasthttp.RequestCtx) error {
 	return nil
 }
 
+// body returns the raw result content: marshalled `static` content or the `file` content.
+func (r Result) body() ([]byte, error) {
 
-func (metrics *Metrics) incrementEndpointCallCount(httpMethod, url string){
+	switch r.Type {
+	case ResultTypeStatic:
 
-	if httpMethod == "" || url == "" {
-		return
-	}
+		if r.Content == nil {
+			return nil, nil
+		}
 
-	metrics.Lock()
-	defer metrics.Unlock()
+		body, err := json.Marshal(r.Content)
 
-	key := endpointCallCountKey(httpMethod, url)
+		if err != nil {
+			return nil, errors.Wrap(err, "Result content marshalling error")
+		}
 
-	metrics.endpointCallCounts[key]++
-}
+		return body, nil
 
-func (metrics *Metrics) GetEndpointCallCount(httpMethod, url string) int{
-	metrics.Lock()
-	defer metrics.Unlock()
+	case ResultTypeFile:
 
-	return metrics.endpointCallCounts[endpointCallCountKey(httpMethod, url)]
-}
+		if v, ok := r.Content.(map[string]interface{}); ok {
+
+			f, err := ioutil.ReadFile(fmt.Sprint(v["path"]))
 
-func endpointCallCountKey(httpMethod, url string) string{
-	return fmt.Sprintf("%s-%s", httpMethod, url)
-}
\ No newline at end of file
+			if err != nil {
+				return nil, errors.Wrap(err, "Result file can not read")
+			}
+
+			return f, nil
+		}
+
+		return nil, errors.New("Result file content must have a path")
+
+	case "":
+		return nil, nil
+	}
+
+	return nil, errors.Errorf("Result type [%s] is not supported", r.Type)
+}
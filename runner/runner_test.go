@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestLoadInterpolates(t *testing.T) {
+
+	t.Setenv("GAOS_TEST_UPSTREAM", "http://payment:8080")
+
+	tests := []struct {
+		name     string
+		document string
+		upstream string
+		port     int32
+		err      bool
+	}{
+		{
+			name:     "variable",
+			document: `{"service": {"search": {"port": 8080, "fallback": {"host": "${GAOS_TEST_UPSTREAM}"}}}}`,
+			upstream: "http://payment:8080",
+			port:     8080,
+		},
+		{
+			name:     "default",
+			document: `{"service": {"search": {"port": ${GAOS_TEST_PORT:-9090}, "fallback": {"host": "${GAOS_TEST_UPSTREAM}"}}}}`,
+			upstream: "http://payment:8080",
+			port:     9090,
+		},
+		{
+			name:     "required",
+			document: `{"service": {"search": {"port": ${GAOS_TEST_PORT:?port is required}}}}`,
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			g, err := Load(strings.NewReader(tt.document))
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("Load succeeded, want error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			service := g.Service["search"]
+
+			if service.Port != tt.port {
+				t.Errorf("port = %d, want %d", service.Port, tt.port)
+			}
+
+			if service.Fallback.Host != tt.upstream {
+				t.Errorf("host = %s, want %s", service.Fallback.Host, tt.upstream)
+			}
+		})
+	}
+}
+
+func TestReadDefaultsManagementPort(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		management string
+		port       int32
+	}{
+		{
+			name: "missing",
+			port: DefaultManagementPort,
+		},
+		{
+			name:       "port",
+			management: `, "management": {"port": 9190}`,
+			port:       9190,
+		},
+		{
+			name:       "journal only",
+			management: `, "management": {"journal": 10}`,
+			port:       DefaultManagementPort,
+		},
+		{
+			name:       "disabled",
+			management: `, "management": {"disabled": true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			path := filepath.Join(t.TempDir(), "scenario.json")
+			document := `{"service": {"search": {"port": 8080}}` + tt.management + `}`
+
+			if err := os.WriteFile(path, []byte(document), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			g, err := Read(path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if g.Management.Port != tt.port {
+				t.Errorf("port = %d, want %d", g.Management.Port, tt.port)
+			}
+		})
+	}
+}
+
+func TestRedirectForwardsPath(t *testing.T) {
+
+	action := &Action{Result: Result{Type: ResultTypeRedirect, Content: map[string]interface{}{"host": echoUpstream(t)}}}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/payments/42")
+
+	if err := action.Execute(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(ctx.Response.Body()); got != "/payments/42" {
+		t.Errorf("upstream got %s, want /payments/42", got)
+	}
+}
+
+func TestFallbackForwardsQuery(t *testing.T) {
+
+	g, err := Load(strings.NewReader(`{
+  "service": { "search": { "fallback": { "host": "` + echoUpstream(t) + `" }, "path": {} } }
+}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addresses, err := g.Start(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer g.Shutdown()
+
+	tests := []string{"/payments/42", "/payments/42?expand=items&page=2"}
+
+	for _, uri := range tests {
+
+		_, body, err := fasthttp.Get(nil, "http://"+addresses["search"]+uri)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != uri {
+			t.Errorf("upstream got %s, want %s", body, uri)
+		}
+	}
+}
+
+// echoUpstream starts a server answering with the request URI it received and returns its URL.
+func echoUpstream(t *testing.T) string {
+	t.Helper()
+
+	upstream := &fasthttp.Server{Handler: func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString(string(ctx.RequestURI()))
+	}}
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_ = upstream.Serve(ln)
+	}()
+
+	t.Cleanup(func() {
+		_ = upstream.Shutdown()
+	})
+
+	return "http://" + ln.Addr().String()
+}
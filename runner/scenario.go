@@ -18,6 +18,8 @@ package runner
 
 import (
 	"github.com/pkg/errors"
+	"regexp"
+	"sync"
 	"time"
 )
 
@@ -25,6 +27,7 @@ type Limit struct {
 	s Scenario
 	l int
 	n int
+	sync.Mutex
 }
 
 func NewLimit(s Scenario) *Limit {
@@ -35,7 +38,9 @@ func NewLimit(s Scenario) *Limit {
 	}
 }
 
-func (l *Limit) Execute() (Done, error) {
+func (l *Limit) Execute(_ *Request) (Done, error) {
+	l.Lock()
+	defer l.Unlock()
 
 	l.n++
 
@@ -46,10 +51,18 @@ func (l *Limit) Execute() (Done, error) {
 	return nil, nil
 }
 
+func (l *Limit) reset() {
+	l.Lock()
+	defer l.Unlock()
+
+	l.n = 1
+}
+
 type Rate struct {
 	s Scenario
 	r int
 	n int
+	sync.Mutex
 }
 
 func NewRate(s Scenario) *Rate {
@@ -60,7 +73,9 @@ func NewRate(s Scenario) *Rate {
 	}
 }
 
-func (r *Rate) Execute() (Done, error) {
+func (r *Rate) Execute(_ *Request) (Done, error) {
+	r.Lock()
+	defer r.Unlock()
 
 	r.n++
 
@@ -72,9 +87,17 @@ func (r *Rate) Execute() (Done, error) {
 	return nil, nil
 }
 
+func (r *Rate) reset() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.n = 1
+}
+
 type Duration struct {
 	s        Scenario
 	duration time.Duration
+	clock    Clock
 }
 
 func NewDuration(s Scenario) *Duration {
@@ -82,18 +105,17 @@ func NewDuration(s Scenario) *Duration {
 	return &Duration{
 		s:        s,
 		duration: duration,
+		clock:    wallClock{},
 	}
 }
 
-func (d *Duration) Execute() (Done, error) {
+func (d *Duration) Execute(_ *Request) (Done, error) {
 	done := make(chan bool)
 
-	timer := time.NewTimer(d.duration)
-
 	go func(c <-chan time.Time, d chan bool) {
 		<-c
 		d <- true
-	}(timer.C, done)
+	}(d.clock.After(d.duration), done)
 
 	return done, nil
 }
@@ -101,6 +123,7 @@ func (d *Duration) Execute() (Done, error) {
 type Latency struct {
 	s     Scenario
 	sleep time.Duration
+	clock Clock
 }
 
 func NewLatency(s Scenario) *Latency {
@@ -108,12 +131,13 @@ func NewLatency(s Scenario) *Latency {
 	return &Latency{
 		s:     s,
 		sleep: sleep,
+		clock: wallClock{},
 	}
 }
 
-func (d *Latency) Execute() (Done, error) {
+func (d *Latency) Execute(_ *Request) (Done, error) {
 
-	time.Sleep(d.sleep)
+	d.clock.Sleep(d.sleep)
 
 	return nil, nil
 }
@@ -122,6 +146,7 @@ type Span struct {
 	s     Scenario
 	start *time.Time
 	end   *time.Time
+	clock Clock
 }
 
 func NewSpan(s Scenario) *Span {
@@ -132,22 +157,23 @@ func NewSpan(s Scenario) *Span {
 		s:     s,
 		start: &start,
 		end:   &end,
+		clock: wallClock{},
 	}
 
 	if len(s.Start) == 0 || serr != nil {
 		span.start = nil
 	}
 
-	if len(s.Start) == 0 || eerr != nil {
+	if len(s.End) == 0 || eerr != nil {
 		span.end = nil
 	}
 
 	return span
 }
 
-func (d *Span) Execute() (Done, error) {
+func (d *Span) Execute(_ *Request) (Done, error) {
 
-	n := time.Now()
+	n := d.clock.Now()
 
 	if d.start != nil && d.start.After(n) {
 		return nil, errors.New(n.Format("2006-01-02T15:04:05.999999Z") + " this time is not between to scenario start and end time")
@@ -159,3 +185,34 @@ func (d *Span) Execute() (Done, error) {
 
 	return nil, nil
 }
+
+type Subject struct {
+	s       Scenario
+	pattern *regexp.Regexp
+}
+
+func NewSubject(s Scenario) (*Subject, error) {
+	pattern, err := regexp.Compile(s.Subject)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Subject pattern can not compiled. Value: %s", s.Subject)
+	}
+
+	return &Subject{
+		s:       s,
+		pattern: pattern,
+	}, nil
+}
+
+func (d *Subject) Execute(r *Request) (Done, error) {
+
+	if len(r.Subject) == 0 {
+		return nil, errors.New("Request has no client certificate")
+	}
+
+	if !d.pattern.MatchString(r.Subject) {
+		return nil, errors.New(r.Subject + " client certificate subject does not match scenario subject")
+	}
+
+	return nil, nil
+}
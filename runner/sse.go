@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"io"
+	"strings"
+	"time"
+)
+
+const ContentTypeEventStream = "text/event-stream"
+
+// errSSEDisconnected aborts the chunked body, so the client sees a dropped connection instead of a finished stream.
+var errSSEDisconnected = errors.New("SSE stream disconnected by chaos")
+
+type SSEResult struct {
+	Events []SSEEvent `json:"events"`
+	Retry  int        `json:"retry"`
+	Chaos  SSEChaos   `json:"chaos"`
+}
+
+type SSEEvent struct {
+	Id    string      `json:"id"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+	Delay string      `json:"delay"`
+	delay time.Duration
+}
+
+// SSEChaos disconnects after the given number of events, or replays every event regardless of `Last-Event-ID`.
+type SSEChaos struct {
+	Disconnect        int  `json:"disconnect"`
+	IgnoreLastEventId bool `json:"ignoreLastEventId"`
+}
+
+func (a *Action) serveSSE(ctx *fasthttp.RequestCtx) error {
+
+	result := SSEResult{}
+
+	err := decodeContent(a.Result.Content, &result)
+
+	if err != nil {
+		return errors.Wrap(err, "SSE result content can not parsed")
+	}
+
+	for i := range result.Events {
+		if len(result.Events[i].Delay) == 0 {
+			continue
+		}
+
+		if result.Events[i].delay, err = time.ParseDuration(result.Events[i].Delay); err != nil {
+			return errors.Wrapf(err, "SSE event delay can not parsed. Value: %s", result.Events[i].Delay)
+		}
+	}
+
+	events := result.Events
+
+	if last := string(ctx.Request.Header.Peek("Last-Event-ID")); len(last) > 0 && !result.Chaos.IgnoreLastEventId {
+		for i, e := range events {
+			if e.Id == last {
+				events = events[i+1:]
+				break
+			}
+		}
+	}
+
+	status := a.Status
+
+	if status == 0 {
+		status = fasthttp.StatusOK
+	}
+
+	ctx.SetStatusCode(status)
+	ctx.SetContentType(ContentTypeEventStream)
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+
+	if result.Chaos.Disconnect > 0 && result.Chaos.Disconnect < len(events) {
+		ctx.SetConnectionClose()
+	}
+
+	reader, writer := io.Pipe()
+
+	ctx.SetBodyStream(reader, -1)
+
+	go func() {
+		_ = writer.CloseWithError(streamEvents(bufio.NewWriter(writer), result, events))
+	}()
+
+	return nil
+}
+
+func streamEvents(w *bufio.Writer, result SSEResult, events []SSEEvent) error {
+
+	if result.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", result.Retry)
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	for i, e := range events {
+
+		if result.Chaos.Disconnect > 0 && i >= result.Chaos.Disconnect {
+			return errSSEDisconnected
+		}
+
+		time.Sleep(e.delay)
+
+		if err := writeEvent(w, e); err != nil {
+			return err
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeEvent(w *bufio.Writer, e SSEEvent) error {
+
+	if len(e.Id) > 0 {
+		fmt.Fprintf(w, "id: %s\n", e.Id)
+	}
+
+	if len(e.Event) > 0 {
+		fmt.Fprintf(w, "event: %s\n", e.Event)
+	}
+
+	data, ok := e.Data.(string)
+
+	if !ok {
+		body, err := json.Marshal(e.Data)
+
+		if err != nil {
+			return errors.Wrap(err, "SSE event data marshalling error")
+		}
+
+		data = string(body)
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+
+	_, err := w.WriteString("\n")
+
+	return err
+}
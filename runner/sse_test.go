@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestServeSSE(t *testing.T) {
+
+	events := []interface{}{
+		map[string]interface{}{"id": "1", "data": "first"},
+		map[string]interface{}{"id": "2", "data": "second"},
+	}
+
+	tests := []struct {
+		name     string
+		chaos    map[string]interface{}
+		want     string
+		complete bool
+	}{
+		{name: "complete", want: "id: 1\ndata: first\n\nid: 2\ndata: second\n\n", complete: true},
+		{name: "disconnect", chaos: map[string]interface{}{"disconnect": 1}, want: "id: 1\ndata: first\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			action := &Action{Result: Result{Content: map[string]interface{}{"events": events, "chaos": tt.chaos}}}
+
+			ln := fasthttputil.NewInmemoryListener()
+			defer ln.Close()
+
+			go func() {
+				_ = fasthttp.Serve(ln, func(ctx *fasthttp.RequestCtx) {
+					if err := action.serveSSE(ctx); err != nil {
+						t.Error(err)
+					}
+				})
+			}()
+
+			conn, err := ln.Dial()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			if _, err := conn.Write([]byte("GET /events HTTP/1.1\r\nHost: gaos\r\n\r\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := ioutil.ReadAll(res.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+
+			if tt.complete && err != nil {
+				t.Errorf("stream ended with %v, want a complete body", err)
+			}
+
+			if !tt.complete && err == nil {
+				t.Error("stream ended cleanly, want a dropped connection")
+			}
+
+			if !tt.complete && !res.Close {
+				t.Error("response does not close the connection")
+			}
+		})
+	}
+}
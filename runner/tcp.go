@@ -0,0 +1,470 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ToxicTypeLatency   = "latency"
+	ToxicTypeBandwidth = "bandwidth"
+	ToxicTypeReset     = "reset"
+	ToxicTypeTimeout   = "timeout"
+	ToxicTypeSlicer    = "slicer"
+)
+
+const (
+	ToxicDirectionUpstream   = "upstream"
+	ToxicDirectionDownstream = "downstream"
+)
+
+// Toxic degrades the data flowing through a tcp service.
+// Like scenarios, it only applies between `start` and `end`, and with `rate` only to every rate-th connection.
+type Toxic struct {
+	Type      string `json:"type"`
+	Direction string `json:"direction"`
+	Latency   string `json:"latency"`
+	Bandwidth int    `json:"bandwidth"`
+	Size      int    `json:"size"`
+	Delay     string `json:"delay"`
+	Timeout   string `json:"timeout"`
+	Rate      int    `json:"rate"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Disabled  bool   `json:"disabled"`
+}
+
+type ToxicState struct {
+	Service  string `json:"service"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Enabled  bool   `json:"enabled"`
+	Admitted int    `json:"admitted"`
+}
+
+type toxic struct {
+	Toxic
+	name        string
+	latency     time.Duration
+	delay       time.Duration
+	timeout     time.Duration
+	span        *Span
+	enabled     bool
+	connections int
+	admitted    int
+	sync.Mutex
+}
+
+type tcpProxy struct {
+	name        string
+	upstream    string
+	toxics      []*toxic
+	connections map[net.Conn]bool
+	closed      bool
+	sync.Mutex
+}
+
+func newToxic(name string, t Toxic) (*toxic, error) {
+
+	x := &toxic{Toxic: t, name: name, enabled: !t.Disabled}
+
+	if len(x.Direction) == 0 {
+		x.Direction = ToxicDirectionDownstream
+	}
+
+	if x.Direction != ToxicDirectionUpstream && x.Direction != ToxicDirectionDownstream {
+		return nil, errors.Errorf("Toxic [%s] direction must be '%s' or '%s'", name, ToxicDirectionUpstream, ToxicDirectionDownstream)
+	}
+
+	var err error
+
+	for _, d := range []struct {
+		value string
+		into  *time.Duration
+	}{{t.Latency, &x.latency}, {t.Delay, &x.delay}, {t.Timeout, &x.timeout}} {
+		if len(d.value) == 0 {
+			continue
+		}
+
+		if *d.into, err = time.ParseDuration(d.value); err != nil {
+			return nil, errors.Wrapf(err, "Toxic [%s] duration can not parsed. Value: %s", name, d.value)
+		}
+	}
+
+	switch t.Type {
+	case ToxicTypeLatency:
+		if x.latency <= 0 {
+			return nil, errors.Errorf("Toxic [%s] latency must be a positive duration", name)
+		}
+	case ToxicTypeReset, ToxicTypeTimeout:
+	case ToxicTypeBandwidth:
+		if t.Bandwidth <= 0 {
+			return nil, errors.Errorf("Toxic [%s] bandwidth must be positive KB/s", name)
+		}
+	case ToxicTypeSlicer:
+		if t.Size <= 0 {
+			return nil, errors.Errorf("Toxic [%s] size must be positive bytes", name)
+		}
+	default:
+		return nil, errors.Errorf("Unexpected toxic type given: %s. Available: '%s', '%s', '%s', '%s', '%s'", t.Type, ToxicTypeLatency, ToxicTypeBandwidth, ToxicTypeReset, ToxicTypeTimeout, ToxicTypeSlicer)
+	}
+
+	if len(t.Start) > 0 || len(t.End) > 0 {
+		x.span = NewSpan(Scenario{Start: t.Start, End: t.End})
+	}
+
+	return x, nil
+}
+
+func (g *Runner) runToTCPService(service *Service, name string) (string, error) {
+
+	if len(service.Upstream) == 0 {
+		return "", errors.New("TCP service requires an `upstream` address")
+	}
+
+	proxy := &tcpProxy{name: name, upstream: service.Upstream, connections: map[net.Conn]bool{}}
+
+	for k, t := range service.Toxics {
+
+		x, err := newToxic(k, *t)
+
+		if err != nil {
+			return "", err
+		}
+
+		proxy.toxics = append(proxy.toxics, x)
+	}
+
+	sort.Slice(proxy.toxics, func(i, j int) bool {
+		return proxy.toxics[i].name < proxy.toxics[j].name
+	})
+
+	ln, err := net.Listen("tcp4", fmt.Sprintf(":%d", service.Port))
+
+	if err != nil {
+		return "", err
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go proxy.handle(conn)
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	g.track(server{name: fmt.Sprint(port), kind: "TCP", listener: ln, shutdown: func() error {
+		_ = ln.Close()
+		proxy.closeAll()
+		return nil
+	}})
+
+	g.mutex.Lock()
+
+	if g.proxies == nil {
+		g.proxies = map[string]*tcpProxy{}
+	}
+
+	g.proxies[name] = proxy
+
+	g.mutex.Unlock()
+
+	logger.Info(fmt.Sprintf("[%d] TCP proxy started for [%s] to %s", port, name, service.Upstream))
+
+	return ln.Addr().String(), nil
+}
+
+func (p *tcpProxy) handle(client net.Conn) {
+
+	if !p.register(client) {
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", p.upstream, 10*time.Second)
+
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "[%s] Upstream %s can not reached", p.name, p.upstream))
+		_ = client.Close()
+
+		p.Lock()
+		delete(p.connections, client)
+		p.Unlock()
+
+		return
+	}
+
+	if !p.register(upstream) {
+		_ = client.Close()
+		return
+	}
+
+	var toxics []*toxic
+
+	for _, t := range p.toxics {
+		if t.admit() {
+			toxics = append(toxics, t)
+		}
+	}
+
+	closer := &sync.Once{}
+
+	closeBoth := func() {
+		closer.Do(func() {
+			_ = client.Close()
+			_ = upstream.Close()
+
+			p.Lock()
+			delete(p.connections, client)
+			delete(p.connections, upstream)
+			p.Unlock()
+		})
+	}
+
+	go p.pipe(upstream, client, ToxicDirectionUpstream, toxics, closeBoth)
+
+	p.pipe(client, upstream, ToxicDirectionDownstream, toxics, closeBoth)
+}
+
+// pipe copies src to dst chunk by chunk, applying the active toxics of the direction to every chunk.
+func (p *tcpProxy) pipe(dst, src net.Conn, direction string, toxics []*toxic, closeBoth func()) {
+
+	defer closeBoth()
+
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := src.Read(buf)
+
+		if n > 0 {
+
+			chunk := buf[:n]
+			sliced := false
+
+			for _, t := range toxics {
+
+				if t.Direction != direction || !t.active() {
+					continue
+				}
+
+				switch t.Type {
+				case ToxicTypeLatency:
+					time.Sleep(t.latency)
+				case ToxicTypeBandwidth:
+					time.Sleep(time.Duration(len(chunk)) * time.Second / time.Duration(t.Bandwidth*1024))
+				case ToxicTypeReset:
+					reset(dst)
+					reset(src)
+					return
+				case ToxicTypeTimeout:
+					if t.timeout > 0 {
+						time.Sleep(t.timeout)
+						return
+					}
+
+					_, _ = io.Copy(ioutil.Discard, src)
+					return
+				case ToxicTypeSlicer:
+					sliced = true
+				}
+			}
+
+			if sliced {
+				err = p.slice(dst, chunk, toxics, direction)
+			} else {
+				_, err = dst.Write(chunk)
+			}
+
+			if err != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// slice writes the chunk in partial writes of the first active slicer.
+func (p *tcpProxy) slice(dst net.Conn, chunk []byte, toxics []*toxic, direction string) error {
+
+	for _, t := range toxics {
+
+		if t.Type != ToxicTypeSlicer || t.Direction != direction || !t.active() {
+			continue
+		}
+
+		for len(chunk) > 0 {
+			n := t.Size
+
+			if n > len(chunk) {
+				n = len(chunk)
+			}
+
+			if _, err := dst.Write(chunk[:n]); err != nil {
+				return err
+			}
+
+			chunk = chunk[n:]
+
+			time.Sleep(t.delay)
+		}
+
+		return nil
+	}
+
+	_, err := dst.Write(chunk)
+
+	return err
+}
+
+// register tracks the connection for closeAll, or closes it when the proxy is already shut down.
+func (p *tcpProxy) register(conn net.Conn) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.closed {
+		_ = conn.Close()
+		return false
+	}
+
+	p.connections[conn] = true
+
+	return true
+}
+
+func (p *tcpProxy) closeAll() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.closed = true
+
+	for conn := range p.connections {
+		_ = conn.Close()
+	}
+}
+
+func reset(conn net.Conn) {
+	if c, ok := conn.(*net.TCPConn); ok {
+		_ = c.SetLinger(0)
+	}
+
+	_ = conn.Close()
+}
+
+// admit counts a new connection and reports whether the toxic applies to it.
+func (t *toxic) admit() bool {
+	t.Lock()
+	defer t.Unlock()
+
+	t.connections++
+
+	if t.Rate > 0 && t.connections%t.Rate != 0 {
+		return false
+	}
+
+	t.admitted++
+
+	return true
+}
+
+func (t *toxic) active() bool {
+	t.Lock()
+	enabled := t.enabled
+	t.Unlock()
+
+	if !enabled {
+		return false
+	}
+
+	if t.span != nil {
+		if _, err := t.span.Execute(&Request{ctx: context.Background()}); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetToxic enables or disables a toxic of a running tcp service.
+func (g *Runner) SetToxic(service, name string, enabled bool) error {
+
+	g.mutex.Lock()
+	proxy, ok := g.proxies[service]
+	g.mutex.Unlock()
+
+	if !ok {
+		return errors.Errorf("TCP service [%s] is not running", service)
+	}
+
+	for _, t := range proxy.toxics {
+		if t.name == name {
+			t.Lock()
+			t.enabled = enabled
+			t.Unlock()
+
+			logger.Info(fmt.Sprintf("[%s] Toxic [%s] enabled: %t", service, name, enabled))
+
+			return nil
+		}
+	}
+
+	return errors.Errorf("Toxic [%s] is not defined for service [%s]", name, service)
+}
+
+// Toxics returns the state of every toxic of running tcp services.
+func (g *Runner) Toxics() []ToxicState {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	result := make([]ToxicState, 0)
+
+	for name, proxy := range g.proxies {
+		for _, t := range proxy.toxics {
+			t.Lock()
+			result = append(result, ToxicState{Service: name, Name: t.name, Type: t.Type, Enabled: t.enabled, Admitted: t.admitted})
+			t.Unlock()
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
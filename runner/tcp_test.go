@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewToxic(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		toxic     Toxic
+		direction string
+		latency   time.Duration
+		err       bool
+	}{
+		{name: "latency", toxic: Toxic{Type: ToxicTypeLatency, Latency: "100ms"}, direction: ToxicDirectionDownstream, latency: 100 * time.Millisecond},
+		{name: "upstream", toxic: Toxic{Type: ToxicTypeReset, Direction: ToxicDirectionUpstream}, direction: ToxicDirectionUpstream},
+		{name: "bandwidth", toxic: Toxic{Type: ToxicTypeBandwidth, Bandwidth: 10}, direction: ToxicDirectionDownstream},
+		{name: "slicer", toxic: Toxic{Type: ToxicTypeSlicer, Size: 8, Delay: "1ms"}, direction: ToxicDirectionDownstream},
+		{name: "timeout", toxic: Toxic{Type: ToxicTypeTimeout}, direction: ToxicDirectionDownstream},
+		{name: "missing latency", toxic: Toxic{Type: ToxicTypeLatency}, err: true},
+		{name: "zero latency", toxic: Toxic{Type: ToxicTypeLatency, Latency: "0s"}, err: true},
+		{name: "invalid latency", toxic: Toxic{Type: ToxicTypeLatency, Latency: "soon"}, err: true},
+		{name: "zero bandwidth", toxic: Toxic{Type: ToxicTypeBandwidth}, err: true},
+		{name: "zero size", toxic: Toxic{Type: ToxicTypeSlicer}, err: true},
+		{name: "direction", toxic: Toxic{Type: ToxicTypeReset, Direction: "sideways"}, err: true},
+		{name: "type", toxic: Toxic{Type: "jitter"}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			x, err := newToxic(tt.name, tt.toxic)
+
+			if tt.err {
+				if err == nil {
+					t.Fatalf("newToxic(%+v) succeeded, want error", tt.toxic)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("newToxic(%+v) failed: %v", tt.toxic, err)
+			}
+
+			if x.Direction != tt.direction {
+				t.Errorf("direction = %s, want %s", x.Direction, tt.direction)
+			}
+
+			if x.latency != tt.latency {
+				t.Errorf("latency = %v, want %v", x.latency, tt.latency)
+			}
+		})
+	}
+}
+
+func TestToxicAdmit(t *testing.T) {
+
+	x, err := newToxic("every-third", Toxic{Type: ToxicTypeReset, Rate: 3})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var admitted []bool
+
+	for i := 0; i < 6; i++ {
+		admitted = append(admitted, x.admit())
+	}
+
+	want := []bool{false, false, true, false, false, true}
+
+	for i := range want {
+		if admitted[i] != want[i] {
+			t.Errorf("admit() #%d = %t, want %t", i+1, admitted[i], want[i])
+		}
+	}
+
+	if !x.active() {
+		t.Error("active() = false, want true for an enabled toxic without a span")
+	}
+}
+
+func TestToxicSpan(t *testing.T) {
+
+	layout := "2006-01-02T15:04:05.999999Z"
+	past := time.Now().UTC().Add(-time.Hour).Format(layout)
+	future := time.Now().UTC().Add(time.Hour).Format(layout)
+
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		active bool
+	}{
+		{name: "started", start: past, active: true},
+		{name: "not started", start: future},
+		{name: "ended", end: past},
+		{name: "not ended", end: future, active: true},
+		{name: "between", start: past, end: future, active: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			x, err := newToxic(tt.name, Toxic{Type: ToxicTypeReset, Start: tt.start, End: tt.end})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := x.active(); got != tt.active {
+				t.Errorf("active() = %t, want %t", got, tt.active)
+			}
+		})
+	}
+}
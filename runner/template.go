@@ -0,0 +1,402 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// placeholder is a template parameter reference, e.g. `{{latency}}`.
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// Template is a parameterized scenario, instantiated by scenarios with `template` and `args`.
+// A parameter with a null default is required.
+type Template struct {
+	Params   map[string]interface{} `json:"params"`
+	Scenario map[string]interface{} `json:"scenario"`
+}
+
+// expandScenarios resolves `template` and `extends` of every scenario in place. Fields missing from the scenario
+// document are inherited, except `name`. Objects are merged and `content` is replaced as a whole.
+func (g *Runner) expandScenarios() error {
+
+	expanded := map[string]map[string]interface{}{}
+
+	for name := range g.Scenario {
+		if _, err := g.expand(name, nil, expanded); err != nil {
+			return err
+		}
+	}
+
+	for name, m := range expanded {
+
+		s := g.Scenario[name]
+
+		body, err := json.Marshal(m)
+
+		if err != nil {
+			return errors.Wrapf(err, "Scenario [%s] can not expanded", name)
+		}
+
+		result := Scenario{}
+
+		err = json.Unmarshal(body, &result)
+
+		if err != nil {
+			return errors.Wrapf(err, "Scenario [%s] can not expanded", name)
+		}
+
+		result.Extends = s.Extends
+		result.Template = s.Template
+		result.Args = s.Args
+
+		*s = result
+	}
+
+	return nil
+}
+
+// use appends a named executable, traced as a child span of the request.
+func (s *Scenario) use(name string, e Executable) {
+	s.executables = append(s.executables, traced(name, e))
+	s.names = append(s.names, name)
+}
+
+// UnmarshalJSON keeps the scenario document, so inheritance can tell fields given as zero from missing ones.
+func (s *Scenario) UnmarshalJSON(data []byte) error {
+
+	type plain Scenario
+
+	err := json.Unmarshal(data, (*plain)(s))
+
+	if err != nil {
+		return err
+	}
+
+	s.raw = append(json.RawMessage(nil), data...)
+
+	return nil
+}
+
+// Expand resolves templates and inheritance of every scenario, to inspect the effective scenarios without starting servers.
+func (g *Runner) Expand() error {
+	return g.expandScenarios()
+}
+
+func (g *Runner) expand(name string, stack []string, expanded map[string]map[string]interface{}) (map[string]interface{}, error) {
+
+	if m, ok := expanded[name]; ok {
+		return m, nil
+	}
+
+	for _, v := range stack {
+		if v == name {
+			return nil, errors.Errorf("Scenario [%s] extends itself: %s", name, strings.Join(append(stack, name), " -> "))
+		}
+	}
+
+	s, ok := g.Scenario[name]
+
+	if ok && s == nil {
+		return nil, errors.Errorf("Scenario [%s] is empty", name)
+	}
+
+	if !ok {
+		return nil, errors.Errorf("Scenario [%s] can not found, extended by [%s]", name, stack[len(stack)-1])
+	}
+
+	m, err := fields(s)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Scenario [%s] can not expanded", name)
+	}
+
+	delete(m, "extends")
+	delete(m, "template")
+	delete(m, "args")
+
+	base := s.Extends
+
+	if len(s.Template) > 0 {
+
+		instance, err := g.instantiate(s.Template, s.Args)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Scenario [%s] can not expanded", name)
+		}
+
+		if v, ok := instance["extends"].(string); ok && len(base) == 0 {
+			base = v
+		}
+
+		delete(instance, "extends")
+
+		m = merge(instance, m)
+	}
+
+	if len(base) > 0 {
+
+		parent, err := g.expand(base, append(stack, name), expanded)
+
+		if err != nil {
+			return nil, err
+		}
+
+		inherited := copyValue(parent).(map[string]interface{})
+
+		delete(inherited, "name")
+
+		m = merge(inherited, m)
+	}
+
+	expanded[name] = m
+
+	return m, nil
+}
+
+// instantiate returns the scenario fields of a template with its parameters substituted.
+func (g *Runner) instantiate(name string, args map[string]interface{}) (map[string]interface{}, error) {
+
+	t, ok := g.Template[name]
+
+	if !ok || t == nil {
+		return nil, errors.Errorf("Template [%s] can not found", name)
+	}
+
+	values := map[string]interface{}{}
+
+	for k, v := range t.Params {
+		values[k] = v
+	}
+
+	for k, v := range args {
+
+		if _, ok := t.Params[k]; !ok {
+			return nil, errors.Errorf("Template [%s] has no parameter [%s]", name, k)
+		}
+
+		values[k] = v
+	}
+
+	for k, v := range values {
+		if v == nil {
+			return nil, errors.Errorf("Template [%s] requires parameter [%s]", name, k)
+		}
+	}
+
+	result, err := substitute(copyValue(t.Scenario), values)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Template [%s] can not instantiated", name)
+	}
+
+	m, _ := result.(map[string]interface{})
+
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+
+	return m, nil
+}
+
+// substitute replaces placeholders in strings. A string holding only a placeholder takes the type of its value,
+// so `"status": "{{status}}"` becomes a number.
+func substitute(v interface{}, values map[string]interface{}) (interface{}, error) {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+
+		for k, e := range t {
+
+			r, err := substitute(e, values)
+
+			if err != nil {
+				return nil, err
+			}
+
+			t[k] = r
+		}
+
+		return t, nil
+
+	case []interface{}:
+
+		for i, e := range t {
+
+			r, err := substitute(e, values)
+
+			if err != nil {
+				return nil, err
+			}
+
+			t[i] = r
+		}
+
+		return t, nil
+
+	case string:
+
+		if m := placeholder.FindStringSubmatch(t); m != nil && m[0] == t {
+
+			value, ok := values[m[1]]
+
+			if !ok {
+				return nil, errors.Errorf("Parameter [%s] is not declared", m[1])
+			}
+
+			return value, nil
+		}
+
+		var err error
+
+		result := placeholder.ReplaceAllStringFunc(t, func(s string) string {
+
+			key := placeholder.FindStringSubmatch(s)[1]
+
+			value, ok := values[key]
+
+			if !ok {
+				err = errors.Errorf("Parameter [%s] is not declared", key)
+				return s
+			}
+
+			return fmt.Sprint(value)
+		})
+
+		return result, err
+	}
+
+	return v, nil
+}
+
+// fields returns the fields given in the scenario document, or the non-zero ones of scenarios built in Go,
+// keeping `content` as is.
+func fields(s *Scenario) (map[string]interface{}, error) {
+
+	m := map[string]interface{}{}
+
+	if s.raw != nil {
+		return m, json.Unmarshal(s.raw, &m)
+	}
+
+	body, err := json.Marshal(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(body, &m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prune(m)
+
+	return m, nil
+}
+
+func prune(m map[string]interface{}) {
+
+	for k, v := range m {
+
+		if k == "content" {
+			if v == nil {
+				delete(m, k)
+			}
+
+			continue
+		}
+
+		switch t := v.(type) {
+		case map[string]interface{}:
+			prune(t)
+
+			if len(t) == 0 {
+				delete(m, k)
+			}
+		case []interface{}:
+			if len(t) == 0 {
+				delete(m, k)
+			}
+		case string:
+			if len(t) == 0 {
+				delete(m, k)
+			}
+		case float64:
+			if t == 0 {
+				delete(m, k)
+			}
+		case bool:
+			if !t {
+				delete(m, k)
+			}
+		case nil:
+			delete(m, k)
+		}
+	}
+}
+
+// merge writes override onto base, merging nested objects except `content`.
+func merge(base, override map[string]interface{}) map[string]interface{} {
+
+	for k, v := range override {
+
+		b, bok := base[k].(map[string]interface{})
+		o, ook := v.(map[string]interface{})
+
+		if bok && ook && k != "content" {
+			base[k] = merge(b, o)
+			continue
+		}
+
+		base[k] = v
+	}
+
+	return base
+}
+
+func copyValue(v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+
+		c := make(map[string]interface{}, len(t))
+
+		for k, e := range t {
+			c[k] = copyValue(e)
+		}
+
+		return c
+
+	case []interface{}:
+
+		c := make([]interface{}, len(t))
+
+		for i, e := range t {
+			c[i] = copyValue(e)
+		}
+
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const templateScenarios = `{
+  "template": {
+    "unavailable": {
+      "params": { "latency": "0s", "status": null },
+      "scenario": {
+        "extends": "failing",
+        "latency": "{{latency}}",
+        "ignore": { "status": "{{status}}", "result": { "type": "static", "content": { "message": "unavailable for {{latency}}" } } }
+      }
+    }
+  },
+  "scenario": {
+    "failing": {
+      "name": "failing",
+      "rate": 3,
+      "accept": { "status": 200, "result": { "type": "static", "content": { "id": 1, "tags": ["a"] } } },
+      "ignore": { "status": 500 }
+    },
+    "slow-failing": { "extends": "failing", "latency": "2s" },
+    "always-failing": { "extends": "slow-failing", "rate": 0, "accept": { "status": 0 } },
+    "replaced": { "extends": "failing", "accept": { "result": { "content": { "name": "x" } } } },
+    "gateway-timeout": { "template": "unavailable", "args": { "latency": "5s", "status": 504 } },
+    "custom-timeout": { "template": "unavailable", "args": { "status": 503 }, "latency": "1s" }
+  }
+}`
+
+func TestExpandScenarios(t *testing.T) {
+
+	g, err := Load(strings.NewReader(templateScenarios))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Expand(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		scenario     string
+		name         string
+		latency      string
+		rate         int
+		acceptStatus int
+		ignoreStatus int
+		content      string
+	}{
+		{scenario: "slow-failing", latency: "2s", rate: 3, acceptStatus: 200, ignoreStatus: 500, content: `{"id":1,"tags":["a"]}`},
+		{scenario: "always-failing", latency: "2s", rate: 0, acceptStatus: 0, ignoreStatus: 500, content: `{"id":1,"tags":["a"]}`},
+		{scenario: "replaced", rate: 3, acceptStatus: 200, ignoreStatus: 500, content: `{"name":"x"}`},
+		{scenario: "gateway-timeout", latency: "5s", rate: 3, acceptStatus: 200, ignoreStatus: 504, content: `{"id":1,"tags":["a"]}`},
+		{scenario: "custom-timeout", latency: "1s", rate: 3, acceptStatus: 200, ignoreStatus: 503, content: `{"id":1,"tags":["a"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+
+			s := g.Scenario[tt.scenario]
+
+			if s.Name != tt.name {
+				t.Errorf("name = %q, want %q", s.Name, tt.name)
+			}
+
+			if s.Latency != tt.latency {
+				t.Errorf("latency = %q, want %q", s.Latency, tt.latency)
+			}
+
+			if s.Rate != tt.rate {
+				t.Errorf("rate = %d, want %d", s.Rate, tt.rate)
+			}
+
+			if s.Accept.Status != tt.acceptStatus {
+				t.Errorf("accept status = %d, want %d", s.Accept.Status, tt.acceptStatus)
+			}
+
+			if s.Ignore.Status != tt.ignoreStatus {
+				t.Errorf("ignore status = %d, want %d", s.Ignore.Status, tt.ignoreStatus)
+			}
+
+			if got := marshal(t, s.Accept.Result.Content); got != tt.content {
+				t.Errorf("accept content = %s, want %s", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestExpandScenariosErrors(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		document string
+		err      string
+	}{
+		{name: "null", document: `{"scenario": {"x": null}}`, err: "Scenario [x] is empty"},
+		{name: "null base", document: `{"scenario": {"x": null, "y": {"extends": "x"}}}`, err: "Scenario [x] is empty"},
+		{name: "undefined base", document: `{"scenario": {"y": {"extends": "x"}}}`, err: "Scenario [x] can not found, extended by [y]"},
+		{name: "cycle", document: `{"scenario": {"a": {"extends": "b"}, "b": {"extends": "a"}}}`, err: "extends itself"},
+		{name: "undefined template", document: `{"scenario": {"a": {"template": "t"}}}`, err: "Template [t] can not found"},
+		{name: "required parameter", document: `{"template": {"t": {"params": {"status": null}, "scenario": {}}}, "scenario": {"a": {"template": "t"}}}`, err: "Template [t] requires parameter [status]"},
+		{name: "unknown parameter", document: `{"template": {"t": {"params": {}, "scenario": {}}}, "scenario": {"a": {"template": "t", "args": {"x": 1}}}}`, err: "Template [t] has no parameter [x]"},
+		{name: "undeclared placeholder", document: `{"template": {"t": {"params": {}, "scenario": {"latency": "{{x}}"}}}, "scenario": {"a": {"template": "t"}}}`, err: "Parameter [x] is not declared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			g, err := Load(strings.NewReader(tt.document))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = g.Expand()
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expand() = %v, want error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+
+	values := map[string]interface{}{"latency": "5s", "status": float64(504)}
+
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{value: "{{status}}", want: `504`},
+		{value: "{{ status }}", want: `504`},
+		{value: "unavailable for {{latency}} with {{status}}", want: `"unavailable for 5s with 504"`},
+		{value: []interface{}{"{{latency}}", true}, want: `["5s",true]`},
+		{value: map[string]interface{}{"status": "{{status}}", "n": float64(1)}, want: `{"n":1,"status":504}`},
+	}
+
+	for _, tt := range tests {
+
+		got, err := substitute(copyValue(tt.value), values)
+
+		if err != nil {
+			t.Errorf("substitute(%v) failed: %v", tt.value, err)
+			continue
+		}
+
+		if s := marshal(t, got); s != tt.want {
+			t.Errorf("substitute(%v) = %s, want %s", tt.value, s, tt.want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+
+	m := map[string]interface{}{
+		"name":    "",
+		"rate":    float64(0),
+		"limit":   float64(2),
+		"skip":    false,
+		"args":    map[string]interface{}{},
+		"accept":  map[string]interface{}{"status": float64(0), "result": map[string]interface{}{"content": map[string]interface{}{"zero": float64(0)}}},
+		"ignore":  map[string]interface{}{"result": map[string]interface{}{"content": nil}},
+		"tags":    []interface{}{},
+		"subject": nil,
+	}
+
+	prune(m)
+
+	if got, want := marshal(t, m), `{"accept":{"result":{"content":{"zero":0}}},"limit":2}`; got != want {
+		t.Errorf("prune() = %s, want %s", got, want)
+	}
+}
+
+func marshal(t *testing.T, v interface{}) string {
+
+	body, err := json.Marshal(v)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"time"
+)
+
+const (
+	TLSChaosExpired   = "expired"
+	TLSChaosHostname  = "hostname"
+	TLSChaosUntrusted = "untrusted"
+)
+
+var defaultHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+type TLS struct {
+	Cert   string     `json:"cert"`
+	Key    string     `json:"key"`
+	Auto   bool       `json:"auto"`
+	Hosts  []string   `json:"hosts"`
+	CA     string     `json:"ca"`
+	Chaos  string     `json:"chaos"`
+	Client *ClientTLS `json:"client"`
+}
+
+// ClientTLS requires client certificates signed by CA, unless they are optional.
+type ClientTLS struct {
+	CA       string `json:"ca"`
+	Optional bool   `json:"optional"`
+}
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func (g *Runner) tlsConfig(t *TLS) (*tls.Config, error) {
+
+	cert, err := g.certificate(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{Certificates: []tls.Certificate{*cert}}
+
+	if t.Client != nil {
+
+		pem, err := ioutil.ReadFile(t.Client.CA)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "TLS client CA can not read")
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("TLS client CA has no PEM certificates: %s", t.Client.CA)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+
+		if t.Client.Optional {
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return config, nil
+}
+
+func (g *Runner) certificate(t *TLS) (*tls.Certificate, error) {
+
+	if len(t.Cert) > 0 && len(t.Key) > 0 && len(t.Chaos) == 0 {
+
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "TLS certificate can not loaded")
+		}
+
+		return &cert, nil
+	}
+
+	if !t.Auto && len(t.Chaos) == 0 {
+		return nil, errors.New("TLS requires `cert` and `key`, or `auto`")
+	}
+
+	ca, err := g.authority()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.CA) > 0 {
+		err = ioutil.WriteFile(t.CA, ca.pem, 0644)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "TLS CA certificate can not exported to %s", t.CA)
+		}
+	}
+
+	hosts := t.Hosts
+
+	if len(hosts) == 0 {
+		hosts = defaultHosts
+	}
+
+	now := time.Now()
+	notBefore, notAfter := now.Add(-time.Hour), now.AddDate(1, 0, 0)
+
+	switch t.Chaos {
+	case "":
+	case TLSChaosExpired:
+		notBefore, notAfter = now.AddDate(0, 0, -2), now.AddDate(0, 0, -1)
+	case TLSChaosHostname:
+		hosts = []string{"gaos.invalid"}
+	case TLSChaosUntrusted:
+		ca, err = newAuthority("Gaos Untrusted CA")
+
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("Unexpected TLS chaos given: %s. Available: '%s', '%s', '%s'", t.Chaos, TLSChaosExpired, TLSChaosHostname, TLSChaosUntrusted)
+	}
+
+	return ca.issue(hosts, notBefore, notAfter)
+}
+
+// authority returns the CA shared by every service of the runner, so clients trust a single exported file.
+func (g *Runner) authority() (*authority, error) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.ca != nil {
+		return g.ca, nil
+	}
+
+	ca, err := newAuthority("Gaos CA")
+
+	if err != nil {
+		return nil, err
+	}
+
+	g.ca = ca
+
+	return ca, nil
+}
+
+func newAuthority(name string) (*authority, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "TLS CA key can not generated")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Gaos"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "TLS CA certificate can not generated")
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "TLS CA certificate can not parsed")
+	}
+
+	return &authority{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+func (a *authority) issue(hosts []string, notBefore, notAfter time.Time) (*tls.Certificate, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "TLS key can not generated")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: hosts[0], Organization: []string{"Gaos"}},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "TLS certificate can not generated")
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der, a.cert.Raw},
+		PrivateKey:  key,
+	}, nil
+}
+
+func serialNumber() *big.Int {
+	n, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return n
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCertificate(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		chaos string
+		check func(err error) bool
+	}{
+		{
+			name:  "auto",
+			chaos: "",
+			check: func(err error) bool { return err == nil },
+		},
+		{
+			name:  "expired",
+			chaos: TLSChaosExpired,
+			check: func(err error) bool {
+				var e x509.CertificateInvalidError
+				return errors.As(err, &e) && e.Reason == x509.Expired
+			},
+		},
+		{
+			name:  "hostname",
+			chaos: TLSChaosHostname,
+			check: func(err error) bool {
+				var e x509.HostnameError
+				return errors.As(err, &e)
+			},
+		},
+		{
+			name:  "untrusted",
+			chaos: TLSChaosUntrusted,
+			check: func(err error) bool {
+				var e x509.UnknownAuthorityError
+				return errors.As(err, &e)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ca := filepath.Join(t.TempDir(), "ca.pem")
+
+			config, err := (&Runner{}).tlsConfig(&TLS{Auto: true, CA: ca, Chaos: tt.chaos})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pool := x509.NewCertPool()
+
+			if !pool.AppendCertsFromPEM(readFile(t, ca)) {
+				t.Fatalf("%s has no PEM certificates", ca)
+			}
+
+			err = handshake(t, config, &tls.Config{RootCAs: pool, ServerName: "localhost"})
+
+			if !tt.check(err) {
+				t.Errorf("handshake = %v, want %q verification result", err, tt.chaos)
+			}
+		})
+	}
+}
+
+func TestCertificateSharesAuthority(t *testing.T) {
+
+	g := &Runner{}
+
+	first, err := g.certificate(&TLS{Auto: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := g.certificate(&TLS{Auto: true, Hosts: []string{"payment"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(first.Certificate[1]) != string(second.Certificate[1]) {
+		t.Error("services of a runner are signed by different CAs")
+	}
+
+	leaf, err := x509.ParseCertificate(second.Certificate[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := leaf.VerifyHostname("payment"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClientTLS(t *testing.T) {
+
+	dir := t.TempDir()
+
+	clients, err := newAuthority("Gaos Test Client CA")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknown, err := newAuthority("Gaos Test Unknown CA")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientCA := filepath.Join(dir, "clients.pem")
+
+	if err := ioutil.WriteFile(clientCA, clients.pem, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orders := clientCertificate(t, clients, "orders")
+	billing := clientCertificate(t, clients, "billing")
+	stranger := clientCertificate(t, unknown, "orders")
+
+	tests := []struct {
+		name     string
+		optional bool
+		cert     *tls.Certificate
+		status   int
+		err      bool
+	}{
+		{name: "required with matching subject", cert: &orders, status: http.StatusOK},
+		{name: "required with other subject", cert: &billing, status: http.StatusForbidden},
+		{name: "required without certificate", err: true},
+		{name: "required with unknown CA", cert: &stranger, err: true},
+		{name: "optional with matching subject", optional: true, cert: &orders, status: http.StatusOK},
+		{name: "optional without certificate", optional: true, status: http.StatusForbidden},
+		{name: "optional with unknown CA", optional: true, cert: &stranger, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			serverCA := filepath.Join(t.TempDir(), "server.pem")
+
+			g, err := Load(strings.NewReader(fmt.Sprintf(`{
+  "service": {
+    "partner": {
+      "tls": { "auto": true, "ca": %q, "client": { "ca": %q, "optional": %t } },
+      "path": { "/api": { "method": "GET", "scenario": "partner" } }
+    }
+  },
+  "scenario": {
+    "partner": {
+      "subject": "CN=orders",
+      "accept": { "status": 200, "result": { "type": "static", "content": { "partner": "orders" } } },
+      "ignore": { "status": 403, "result": { "type": "static", "content": { "error": "forbidden" } } }
+    }
+  }
+}`, serverCA, clientCA, tt.optional)))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			addresses, err := g.Start(ctx)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer g.Shutdown()
+
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(readFile(t, serverCA))
+
+			// The certificate is sent even if the server does not list its CA as acceptable.
+			config := &tls.Config{RootCAs: pool, GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				if tt.cert == nil {
+					return &tls.Certificate{}, nil
+				}
+
+				return tt.cert, nil
+			}}
+
+			_, port, _ := net.SplitHostPort(addresses["partner"])
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+			res, err := client.Get("https://localhost:" + port + "/api")
+
+			if tt.err {
+				if err == nil {
+					_ = res.Body.Close()
+					t.Fatalf("GET = %d, want a TLS error", res.StatusCode)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_ = res.Body.Close()
+
+			if res.StatusCode != tt.status {
+				t.Errorf("GET = %d, want %d", res.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+// clientCertificate issues a client certificate with the given common name.
+func clientCertificate(t *testing.T, ca *authority, cn string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake runs a TLS handshake between a server with config and a client with client, returning the client error.
+func handshake(t *testing.T, config, client *tls.Config) error {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp4", "127.0.0.1:0", config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+
+		if err != nil {
+			return
+		}
+
+		_ = conn.(*tls.Conn).Handshake()
+		_ = conn.Close()
+	}()
+
+	conn, err := tls.Dial("tcp4", ln.Addr().String(), client)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	// TLS 1.3 servers verify client certificates after the client handshake completes, so a rejection
+	// arrives with the first read.
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	_, err = conn.Read(make([]byte, 1))
+
+	var timeout net.Error
+
+	if err == io.EOF || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return nil
+	}
+
+	return err
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const tracerName = "github.com/Trendyol/gaos"
+
+// traceContextKey is the user value holding the context of the server span of a request.
+const traceContextKey = "gaos.trace"
+
+var propagator = propagation.TraceContext{}
+
+type Tracing struct {
+	Endpoint string            `json:"endpoint"`
+	Insecure bool              `json:"insecure"`
+	Name     string            `json:"name"`
+	Headers  map[string]string `json:"headers"`
+}
+
+type tracerProvider struct {
+	provider *sdktrace.TracerProvider
+}
+
+func (t tracerProvider) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return t.provider.Shutdown(ctx)
+}
+
+// tracing returns the tracer of the runner, exporting spans over OTLP/HTTP when an endpoint is configured.
+func (g *Runner) tracing() (trace.Tracer, error) {
+
+	if g.Tracing == nil || len(g.Tracing.Endpoint) == 0 {
+		return trace.NewNoopTracerProvider().Tracer(tracerName), nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(g.Tracing.Endpoint)}
+
+	if g.Tracing.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	if len(g.Tracing.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(g.Tracing.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Trace exporter can not created")
+	}
+
+	name := g.Tracing.Name
+
+	if len(name) == 0 {
+		name = "gaos"
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(name))),
+	)
+
+	g.mutex.Lock()
+	g.closers = append(g.closers, tracerProvider{provider: provider})
+	g.mutex.Unlock()
+
+	return provider.Tracer(tracerName), nil
+}
+
+// traced wraps an executable into a child span of the request, kept open until its Done is signalled.
+func traced(name string, e Executable) Executable {
+	return func(r *Request) (Done, error) {
+
+		ctx := r.ctx
+
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName).Start(ctx, "gaos."+name)
+
+		d, err := e(r)
+
+		if err != nil {
+			span.SetAttributes(attribute.Bool("gaos.ignored", true))
+			span.AddEvent("ignored", trace.WithAttributes(attribute.String("gaos.reason", err.Error())))
+		}
+
+		if d == nil {
+			span.End()
+			return nil, err
+		}
+
+		done := make(chan bool, 1)
+
+		go func() {
+			v := <-d
+			span.End()
+			done <- v
+		}()
+
+		return done, err
+	}
+}
+
+// traceContext returns the context of the server span stored on ctx, if any.
+func traceContext(ctx *fasthttp.RequestCtx) context.Context {
+
+	if v, ok := ctx.UserValue(traceContextKey).(context.Context); ok {
+		return v
+	}
+
+	return context.Background()
+}
+
+func endSpan(span trace.Span, status int) {
+
+	span.SetAttributes(semconv.HTTPStatusCode(status))
+
+	if status >= fasthttp.StatusInternalServerError {
+		span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+	}
+
+	span.End()
+}
+
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTracing(t *testing.T) {
+
+	var mutex sync.Mutex
+	var spans []*tracepb.Span
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		req := &coltracepb.ExportTraceServiceRequest{}
+
+		if r.URL.Path != "/v1/traces" || proto.Unmarshal(body, req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mutex.Lock()
+
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+
+		mutex.Unlock()
+
+		res, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(res)
+	}))
+
+	defer collector.Close()
+
+	g, err := Load(strings.NewReader(`{
+  "service": { "search": { "path": { "/api": { "method": "GET", "scenario": "slow" } } } },
+  "scenario": { "slow": { "latency": "1ms", "accept": { "status": 200, "result": { "type": "static", "content": { "ok": true } } } } },
+  "tracing": { "endpoint": "` + strings.TrimPrefix(collector.URL, "http://") + `", "insecure": true }
+}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses, err := g.Start(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentId := "00f067aa0ba902b7"
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addresses["search"]+"/api", nil)
+	req.Header.Set("traceparent", "00-"+traceId+"-"+parentId+"-01")
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = res.Body.Close()
+
+	// Shutdown flushes the batched spans to the collector.
+	if err := g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	named := map[string]*tracepb.Span{}
+
+	for _, s := range spans {
+		named[s.Name] = s
+
+		if hex.EncodeToString(s.TraceId) != traceId {
+			t.Errorf("span [%s] trace = %x, want %s", s.Name, s.TraceId, traceId)
+		}
+	}
+
+	tests := []struct {
+		span   string
+		parent string
+	}{
+		{span: "GET /api"},
+		{span: "gaos.match", parent: "GET /api"},
+		{span: "gaos.latency", parent: "gaos.match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.span, func(t *testing.T) {
+
+			s, ok := named[tt.span]
+
+			if !ok {
+				t.Fatalf("span [%s] is not exported", tt.span)
+			}
+
+			want, _ := hex.DecodeString(parentId)
+
+			if len(tt.parent) > 0 {
+				p, ok := named[tt.parent]
+
+				if !ok {
+					t.Fatalf("parent span [%s] is not exported", tt.parent)
+				}
+
+				want = p.SpanId
+			}
+
+			if !bytes.Equal(s.ParentSpanId, want) {
+				t.Errorf("parent = %x, want %x", s.ParentSpanId, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/fasthttp/websocket"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var upgrader = websocket.FastHTTPUpgrader{
+	CheckOrigin: func(ctx *fasthttp.RequestCtx) bool {
+		return true
+	},
+}
+
+type WebSocketResult struct {
+	Connect  []interface{}      `json:"connect"`
+	Reply    []WebSocketReply   `json:"reply"`
+	Periodic *WebSocketPeriodic `json:"periodic"`
+	Chaos    WebSocketChaos     `json:"chaos"`
+}
+
+// WebSocketReply sends messages when a client message matches the pattern.
+type WebSocketReply struct {
+	Match    string        `json:"match"`
+	Messages []interface{} `json:"messages"`
+	pattern  *regexp.Regexp
+}
+
+type WebSocketPeriodic struct {
+	Interval string        `json:"interval"`
+	Messages []interface{} `json:"messages"`
+}
+
+// WebSocketChaos applies to every server message. Drop, duplicate and reorder are percentages.
+type WebSocketChaos struct {
+	Drop      int             `json:"drop"`
+	Delay     string          `json:"delay"`
+	Duplicate int             `json:"duplicate"`
+	Reorder   int             `json:"reorder"`
+	Close     *WebSocketClose `json:"close"`
+}
+
+// WebSocketClose closes the connection after the given number of server messages or the given time.
+type WebSocketClose struct {
+	Messages int    `json:"messages"`
+	After    string `json:"after"`
+	Code     int    `json:"code"`
+	Reason   string `json:"reason"`
+}
+
+// errUpgradeRequired rejects plain requests to WebSocket routes with 426 Upgrade Required.
+var errUpgradeRequired = errors.New("WebSocket route requires an upgrade request")
+
+// reorderWindow is how long a held message waits for a later one before it is sent anyway.
+const reorderWindow = 100 * time.Millisecond
+
+type webSocketSession struct {
+	conn    *websocket.Conn
+	chaos   WebSocketChaos
+	delay   time.Duration
+	sent    int
+	held    []byte
+	release *time.Timer
+	closed  bool
+	sync.Mutex
+}
+
+func (a *Action) serveWebSocket(ctx *fasthttp.RequestCtx) error {
+
+	if !websocket.FastHTTPIsWebSocketUpgrade(ctx) {
+		return errUpgradeRequired
+	}
+
+	result := WebSocketResult{}
+
+	err := decodeContent(a.Result.Content, &result)
+
+	if err != nil {
+		return errors.Wrap(err, "WebSocket result content can not parsed")
+	}
+
+	for i := range result.Reply {
+		result.Reply[i].pattern, err = regexp.Compile(result.Reply[i].Match)
+
+		if err != nil {
+			return errors.Wrapf(err, "WebSocket reply pattern can not compiled. Value: %s", result.Reply[i].Match)
+		}
+	}
+
+	var interval, after time.Duration
+
+	if result.Periodic != nil {
+		if interval, err = time.ParseDuration(result.Periodic.Interval); err != nil || interval <= 0 {
+			return errors.Errorf("WebSocket periodic interval must be a positive duration. Value: %s", result.Periodic.Interval)
+		}
+	}
+
+	if result.Chaos.Close != nil && len(result.Chaos.Close.After) > 0 {
+		if after, err = time.ParseDuration(result.Chaos.Close.After); err != nil {
+			return errors.Wrapf(err, "WebSocket close time can not parsed. Value: %s", result.Chaos.Close.After)
+		}
+	}
+
+	delay, _ := time.ParseDuration(result.Chaos.Delay)
+
+	return upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+
+		session := &webSocketSession{conn: conn, chaos: result.Chaos, delay: delay}
+
+		done := make(chan bool)
+
+		defer func() {
+			close(done)
+			session.close(websocket.CloseNormalClosure, "")
+		}()
+
+		for _, m := range result.Connect {
+			session.send(m)
+		}
+
+		if interval > 0 {
+			go func() {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-ticker.C:
+						for _, m := range result.Periodic.Messages {
+							session.send(m)
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+		}
+
+		if after > 0 {
+			timer := time.AfterFunc(after, func() {
+				session.close(result.Chaos.Close.Code, result.Chaos.Close.Reason)
+			})
+
+			defer timer.Stop()
+		}
+
+		for {
+			_, message, err := conn.ReadMessage()
+
+			if err != nil {
+				return
+			}
+
+			for _, reply := range result.Reply {
+				if reply.pattern.Match(message) {
+					for _, m := range reply.Messages {
+						session.send(m)
+					}
+				}
+			}
+		}
+	})
+}
+
+func (s *webSocketSession) send(message interface{}) {
+
+	data, ok := message.(string)
+
+	if !ok {
+		body, err := json.Marshal(message)
+
+		if err != nil {
+			logger.Error(errors.Wrap(err, "WebSocket message marshalling error"))
+			return
+		}
+
+		data = string(body)
+	}
+
+	if s.delay > 0 {
+		time.Sleep(s.delay)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed || chance(s.chaos.Drop) {
+		return
+	}
+
+	if s.held == nil && chance(s.chaos.Reorder) {
+		s.held = []byte(data)
+		s.release = time.AfterFunc(reorderWindow, s.flush)
+		return
+	}
+
+	s.write([]byte(data))
+	s.writeHeld()
+
+	if chance(s.chaos.Duplicate) {
+		s.write([]byte(data))
+	}
+
+	s.closeAfterMessages()
+}
+
+// flush sends the held message when no later message arrived within the reorder window.
+func (s *webSocketSession) flush() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.writeHeld()
+	s.closeAfterMessages()
+}
+
+func (s *webSocketSession) writeHeld() {
+
+	if s.held == nil {
+		return
+	}
+
+	s.release.Stop()
+	s.write(s.held)
+	s.held = nil
+}
+
+func (s *webSocketSession) closeAfterMessages() {
+
+	if c := s.chaos.Close; c != nil && c.Messages > 0 && s.sent >= c.Messages {
+		s.closeLocked(c.Code, c.Reason)
+	}
+}
+
+func (s *webSocketSession) write(data []byte) {
+
+	err := s.conn.WriteMessage(websocket.TextMessage, data)
+
+	if err != nil {
+		logger.Error(errors.Wrap(err, "WebSocket message can not sent"))
+		return
+	}
+
+	s.sent++
+}
+
+func (s *webSocketSession) close(code int, reason string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.closeLocked(code, reason)
+}
+
+func (s *webSocketSession) closeLocked(code int, reason string) {
+
+	if s.closed {
+		return
+	}
+
+	if code == 0 {
+		code = websocket.CloseNormalClosure
+	}
+
+	s.writeHeld()
+	s.closed = true
+
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	_ = s.conn.Close()
+}
+
+// chance reports true percent% of the time.
+func chance(percent int) bool {
+	return percent > 0 && rand.Intn(100) < percent
+}
+
+// decodeContent converts a generic result content into its typed form.
+func decodeContent(content interface{}, v interface{}) error {
+
+	body, err := json.Marshal(content)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestServeWebSocket(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		content map[string]interface{}
+		send    []string
+		want    []string
+		code    int
+		reason  string
+	}{
+		{
+			name:    "connect",
+			content: map[string]interface{}{"connect": []interface{}{map[string]interface{}{"event": "hello"}}},
+			want:    []string{`{"event":"hello"}`},
+		},
+		{
+			name:    "reply",
+			content: map[string]interface{}{"reply": []interface{}{map[string]interface{}{"match": "^ping$", "messages": []interface{}{"pong"}}}},
+			send:    []string{"ping", "other", "ping"},
+			want:    []string{"pong", "pong"},
+		},
+		{
+			name:    "periodic",
+			content: map[string]interface{}{"periodic": map[string]interface{}{"interval": "10ms", "messages": []interface{}{"tick"}}},
+			want:    []string{"tick", "tick", "tick"},
+		},
+		{
+			name: "drop",
+			content: map[string]interface{}{
+				"connect": []interface{}{"a", "b"},
+				"chaos":   map[string]interface{}{"drop": 100, "close": map[string]interface{}{"after": "50ms", "code": 1011}},
+			},
+			code: websocket.CloseInternalServerErr,
+		},
+		{
+			name: "duplicate",
+			content: map[string]interface{}{
+				"connect": []interface{}{"a", "b"},
+				"chaos":   map[string]interface{}{"duplicate": 100},
+			},
+			want: []string{"a", "a", "b", "b"},
+		},
+		{
+			name: "reorder",
+			content: map[string]interface{}{
+				"connect": []interface{}{"a", "b", "c"},
+				"chaos":   map[string]interface{}{"reorder": 100},
+			},
+			want: []string{"b", "a", "c"},
+		},
+		{
+			name: "close after messages",
+			content: map[string]interface{}{
+				"connect": []interface{}{"a", "b", "c"},
+				"chaos":   map[string]interface{}{"close": map[string]interface{}{"messages": 2, "code": 4000, "reason": "chaos"}},
+			},
+			want:   []string{"a", "b"},
+			code:   4000,
+			reason: "chaos",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			action := &Action{Result: Result{Type: ResultTypeWebSocket, Content: tt.content}}
+
+			ln := fasthttputil.NewInmemoryListener()
+			defer ln.Close()
+
+			go func() {
+				_ = fasthttp.Serve(ln, func(ctx *fasthttp.RequestCtx) {
+					if err := action.serveWebSocket(ctx); err != nil {
+						t.Error(err)
+					}
+				})
+			}()
+
+			dialer := websocket.Dialer{NetDial: func(string, string) (net.Conn, error) {
+				return ln.Dial()
+			}}
+
+			conn, _, err := dialer.Dial("ws://gaos/socket", nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+			for _, m := range tt.send {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, want := range tt.want {
+
+				_, message, err := conn.ReadMessage()
+
+				if err != nil {
+					t.Fatalf("message %d: %v, want %q", i, err, want)
+				}
+
+				if string(message) != want {
+					t.Errorf("message %d = %q, want %q", i, message, want)
+				}
+			}
+
+			if tt.code == 0 {
+				return
+			}
+
+			_, message, err := conn.ReadMessage()
+
+			var closed *websocket.CloseError
+
+			if !errors.As(err, &closed) {
+				t.Fatalf("read %q, %v, want close %d", message, err, tt.code)
+			}
+
+			if closed.Code != tt.code || closed.Text != tt.reason {
+				t.Errorf("close = %d %q, want %d %q", closed.Code, closed.Text, tt.code, tt.reason)
+			}
+		})
+	}
+}
+
+func TestWebSocketRequiresUpgrade(t *testing.T) {
+
+	g, err := Load(strings.NewReader(`{
+  "service": { "events": { "path": { "/socket": { "method": "GET", "scenario": "socket" } } } },
+  "scenario": { "socket": { "accept": { "result": { "type": "websocket", "content": { "connect": ["hello"] } } } } }
+}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addresses, err := g.Start(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer g.Shutdown()
+
+	res, err := http.Get("http://" + addresses["events"] + "/socket")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("GET = %d, want %d", res.StatusCode, http.StatusUpgradeRequired)
+	}
+
+	if got := res.Header.Get("Upgrade"); got != "websocket" {
+		t.Errorf("Upgrade = %q, want websocket", got)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	ChaosNone     = "none"
+	ChaosLatency  = "latency"
+	ChaosDuration = "duration"
+	ChaosRate     = "rate"
+	ChaosLimit    = "limit"
+)
+
+type Service struct {
+	Name   string
+	Port   int32
+	Routes []Route
+}
+
+type Route struct {
+	Path   string
+	Method string
+	Chaos  string
+	// Value is the duration of `latency` and `duration`, or the count of `rate` and `limit`.
+	Value string
+	// Status is returned by `accept`, Failure by `ignore`.
+	Status  int
+	Failure int
+	// Recover directs the route to a healthy scenario after the first failure.
+	Recover bool
+}
+
+// node is a commented key of the rendered document. Its value is either raw JSON or child nodes.
+type node struct {
+	comment  []string
+	key      string
+	raw      string
+	children []node
+}
+
+var slug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// document tracks what the rendered file has already named and explained.
+type document struct {
+	names     map[string]bool
+	explained bool
+}
+
+// Render returns a commented scenario document for the given services.
+func Render(services []Service) []byte {
+
+	var serviceNodes, scenarioNodes []node
+
+	d := &document{names: map[string]bool{}}
+
+	for _, s := range services {
+
+		var paths []node
+
+		for _, r := range s.Routes {
+
+			name := d.scenarioName(s.Name, r)
+
+			paths = append(paths, node{
+				comment: []string{fmt.Sprintf("%s %s is handled by scenario %q.", r.Method, r.Path, name)},
+				key:     r.Path,
+				children: []node{
+					{key: "method", raw: quote(r.Method)},
+					{key: "scenario", raw: quote(name)},
+				},
+			})
+
+			scenarioNodes = append(scenarioNodes, d.scenarios(name, s, r)...)
+		}
+
+		serviceNodes = append(serviceNodes, node{
+			key: s.Name,
+			children: []node{
+				{comment: []string{"Port the service listens on."}, key: "port", raw: fmt.Sprint(s.Port)},
+				{comment: []string{"Routes of the service, with `{name}` placeholders for path parameters."}, key: "path", children: paths},
+			},
+		})
+	}
+
+	root := node{
+		children: []node{
+			{
+				comment: []string{
+					"Services gaos listens on. Every path of a service is handled by a scenario.",
+					"Run with: gaos run -s <this file>",
+				},
+				key:      "service",
+				children: serviceNodes,
+			},
+			{
+				comment: []string{
+					"Scenarios decide how a request is answered. Their executables (latency, duration, rate, limit, ...)",
+					"run first: when all of them pass, the `accept` action runs, otherwise `ignore`.",
+					"An action with `direct` switches the route to another scenario for the following requests.",
+				},
+				key:      "scenario",
+				children: scenarioNodes,
+			},
+		},
+	}
+
+	var b bytes.Buffer
+
+	write(&b, root, 0, true)
+
+	b.WriteString("\n")
+
+	return b.Bytes()
+}
+
+func (d *document) scenarios(name string, s Service, r Route) []node {
+
+	var fields []node
+
+	accept := d.action(r.Status, s, r)
+	accept.comment = []string{"Runs when every executable passes."}
+	accept.key = "accept"
+
+	switch r.Chaos {
+	case ChaosLatency:
+		fields = append(fields, node{comment: []string{fmt.Sprintf("Delays every response by %s.", r.Value)}, key: "latency", raw: quote(r.Value)})
+	case ChaosDuration:
+		fields = append(fields, node{comment: []string{fmt.Sprintf("Holds every response until %s have passed since the request arrived.", r.Value)}, key: "duration", raw: quote(r.Value)})
+	case ChaosRate:
+		fields = append(fields, node{comment: []string{fmt.Sprintf("Fails a request whenever the count exceeds %s, then starts counting again.", r.Value)}, key: "rate", raw: r.Value})
+	case ChaosLimit:
+		fields = append(fields, node{comment: []string{fmt.Sprintf("Fails every request once the count exceeds the limit of %s.", r.Value)}, key: "limit", raw: r.Value})
+	default:
+		accept.comment = []string{"There are no executables, so every request runs `accept`."}
+	}
+
+	fields = append(fields, accept)
+
+	failing := r.Chaos == ChaosRate || r.Chaos == ChaosLimit
+
+	if failing {
+		ignore := d.action(r.Failure, s, r)
+		ignore.comment = []string{"Runs when an executable fails."}
+		ignore.key = "ignore"
+
+		if r.Recover {
+			ignore.comment = append(ignore.comment, fmt.Sprintf("After answering, the route switches to scenario %q.", name+"-healthy"))
+			ignore.children = append([]node{{key: "direct", raw: quote(name + "-healthy")}}, ignore.children...)
+		}
+
+		fields = append(fields, ignore)
+	}
+
+	result := []node{{key: name, children: fields}}
+
+	if failing && r.Recover {
+
+		healthy := d.action(r.Status, s, r)
+		healthy.key = "accept"
+
+		result = append(result, node{
+			comment:  []string{fmt.Sprintf("Healthy state of %s %s after its first failure.", r.Method, r.Path)},
+			key:      name + "-healthy",
+			children: []node{healthy},
+		})
+	}
+
+	return result
+}
+
+func (d *document) action(status int, s Service, r Route) node {
+
+	content, _ := json.Marshal(map[string]interface{}{"service": s.Name, "path": r.Path, "status": status})
+
+	var comment []string
+
+	if !d.explained {
+		comment = []string{"`static` returns `content` as JSON. See the README for `file`, `redirect`, `websocket` and `sse`."}
+		d.explained = true
+	}
+
+	return node{
+		children: []node{
+			{key: "status", raw: fmt.Sprint(status)},
+			{
+				comment: comment,
+				key:     "result",
+				children: []node{
+					{key: "type", raw: quote("static")},
+					{key: "content", raw: string(content)},
+				},
+			},
+		},
+	}
+}
+
+func (d *document) scenarioName(service string, r Route) string {
+
+	base := strings.Trim(slug.ReplaceAllString(strings.ToLower(fmt.Sprintf("%s-%s-%s", service, r.Method, r.Path)), "-"), "-")
+	name := base
+
+	for i := 2; d.names[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	d.names[name] = true
+
+	return name
+}
+
+func write(b *bytes.Buffer, n node, depth int, last bool) {
+
+	indent := strings.Repeat("  ", depth)
+
+	for _, c := range n.comment {
+		fmt.Fprintf(b, "%s// %s\n", indent, c)
+	}
+
+	b.WriteString(indent)
+
+	if len(n.key) > 0 {
+		fmt.Fprintf(b, "%s: ", quote(n.key))
+	}
+
+	if n.children == nil && len(n.raw) > 0 {
+		b.WriteString(n.raw)
+	} else {
+		b.WriteString("{\n")
+
+		for i, c := range n.children {
+			write(b, c, depth+1, i == len(n.children)-1)
+		}
+
+		b.WriteString(indent + "}")
+	}
+
+	if depth > 0 {
+		if !last {
+			b.WriteString(",")
+		}
+
+		b.WriteString("\n")
+	}
+}
+
+func quote(s string) string {
+	v, _ := json.Marshal(s)
+	return string(v)
+}
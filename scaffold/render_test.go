@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Trendyol/gaos/runner"
+)
+
+func TestRender(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		services  []Service
+		scenarios []string
+		direct    string
+	}{
+		{
+			name: "latency",
+			services: []Service{{Name: "payment", Port: 8080, Routes: []Route{
+				{Path: "/payments/{id}", Method: "GET", Chaos: ChaosLatency, Value: "2s", Status: 200},
+			}}},
+			scenarios: []string{"payment-get-payments-id"},
+		},
+		{
+			name: "two routes",
+			services: []Service{{Name: "payment", Port: 8080, Routes: []Route{
+				{Path: "/payments", Method: "GET", Chaos: ChaosNone, Status: 200},
+				{Path: "/payments/{id}", Method: "POST", Chaos: ChaosRate, Value: "3", Status: 201, Failure: 503, Recover: true},
+			}}},
+			scenarios: []string{"payment-get-payments", "payment-post-payments-id", "payment-post-payments-id-healthy"},
+			direct:    "payment-post-payments-id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			document := Render(tt.services)
+
+			g, err := runner.Load(bytes.NewReader(document))
+
+			if err != nil {
+				t.Fatalf("Render() is not a valid scenario document: %v\n%s", err, document)
+			}
+
+			for _, s := range tt.services {
+
+				service, ok := g.Service[s.Name]
+
+				if !ok || service.Port != s.Port {
+					t.Fatalf("service [%s] is not rendered on port %d", s.Name, s.Port)
+				}
+
+				if len(service.Path) != len(s.Routes) {
+					t.Errorf("service [%s] has %d paths, want %d", s.Name, len(service.Path), len(s.Routes))
+				}
+
+				for _, r := range s.Routes {
+					if p, ok := service.Path[r.Path]; !ok || p.Method != r.Method {
+						t.Errorf("route %s %s is not rendered", r.Method, r.Path)
+					}
+				}
+			}
+
+			for _, name := range tt.scenarios {
+				if _, ok := g.Scenario[name]; !ok {
+					t.Errorf("scenario [%s] is not rendered", name)
+				}
+			}
+
+			if len(tt.direct) > 0 {
+				if got := g.Scenario[tt.direct].Ignore.Direct; got != tt.direct+"-healthy" {
+					t.Errorf("direct = %s, want %s", got, tt.direct+"-healthy")
+				}
+			}
+		})
+	}
+}
+
+func TestValidPath(t *testing.T) {
+
+	validate := validPath(map[string]bool{"/payments": true})
+
+	tests := []struct {
+		path string
+		err  bool
+	}{
+		{path: "/payments/{id}"},
+		{path: "/payments", err: true},
+		{path: "payments", err: true},
+	}
+
+	for _, tt := range tests {
+		if err := validate(tt.path); (err != nil) != tt.err {
+			t.Errorf("validPath(%s) = %v, want error %t", tt.path, err, tt.err)
+		}
+	}
+}
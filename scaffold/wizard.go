@@ -0,0 +1,291 @@
+/*
+Copyright 2020 The Gaos Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Trendyol/gaos/logger"
+	"github.com/Trendyol/gaos/runner"
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+var chaos = []struct {
+	name        string
+	description string
+}{
+	{ChaosNone, "none - always answer with `accept`"},
+	{ChaosLatency, "latency - delay every response"},
+	{ChaosDuration, "duration - hold every response until a duration passed"},
+	{ChaosRate, "rate - fail a request periodically"},
+	{ChaosLimit, "limit - fail every request after a count"},
+}
+
+// Run asks for services, routes and chaos behaviours, then writes a commented scenario file to path.
+func Run(path string) error {
+
+	if _, err := os.Stat(path); err == nil && !confirm(fmt.Sprintf("%s already exists. Overwrite", path)) {
+		return errors.Errorf("Scenario file already exists: %s", path)
+	}
+
+	var services []Service
+
+	names := map[string]bool{}
+	ports := map[int32]bool{}
+
+	for {
+		s, err := askService(names, ports)
+
+		if err != nil {
+			return err
+		}
+
+		services = append(services, s)
+
+		if !confirm("Add another service") {
+			break
+		}
+	}
+
+	document := Render(services)
+
+	_, err := runner.Load(bytes.NewReader(document))
+
+	if err != nil {
+		return errors.Wrap(err, "Generated scenario is not valid")
+	}
+
+	err = ioutil.WriteFile(path, document, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "Scenario file can not written: %s", path)
+	}
+
+	logger.Info(fmt.Sprintf("Scenario file written: %s. Run it with `gaos run -s %s`", path, path))
+
+	return nil
+}
+
+func askService(names map[string]bool, ports map[int32]bool) (Service, error) {
+
+	name, err := ask("Service name", "", func(v string) error {
+		if len(strings.TrimSpace(v)) == 0 {
+			return errors.New("Service name must be given")
+		}
+
+		if names[v] {
+			return errors.New("Service name is already used")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return Service{}, err
+	}
+
+	port, err := ask("Port", fmt.Sprint(8080+len(names)), func(v string) error {
+		p, err := strconv.ParseInt(v, 10, 32)
+
+		if err != nil || p < 1 || p > 65535 {
+			return errors.New("Port must be between 1 and 65535")
+		}
+
+		if ports[int32(p)] {
+			return errors.New("Port is already used")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return Service{}, err
+	}
+
+	p, _ := strconv.ParseInt(port, 10, 32)
+
+	names[name] = true
+	ports[int32(p)] = true
+
+	s := Service{Name: name, Port: int32(p)}
+
+	routes := map[string]bool{}
+
+	for {
+		r, err := askRoute(routes)
+
+		if err != nil {
+			return Service{}, err
+		}
+
+		s.Routes = append(s.Routes, r)
+
+		if !confirm(fmt.Sprintf("Add another path to %s", name)) {
+			break
+		}
+	}
+
+	return s, nil
+}
+
+func askRoute(routes map[string]bool) (Route, error) {
+
+	r := Route{}
+
+	_, method, err := (&promptui.Select{Label: "Method", Items: methods}).Run()
+
+	if err != nil {
+		return r, err
+	}
+
+	r.Method = method
+
+	path, err := ask("Path, e.g. /orders/{id}", "", validPath(routes))
+
+	if err != nil {
+		return r, err
+	}
+
+	routes[path] = true
+
+	r.Path = path
+
+	items := make([]string, 0, len(chaos))
+
+	for _, c := range chaos {
+		items = append(items, c.description)
+	}
+
+	i, _, err := (&promptui.Select{Label: "Chaos", Items: items}).Run()
+
+	if err != nil {
+		return r, err
+	}
+
+	r.Chaos = chaos[i].name
+
+	switch r.Chaos {
+	case ChaosLatency, ChaosDuration:
+		r.Value, err = ask("Duration, e.g. 500ms or 2s", "1s", func(v string) error {
+			_, err := time.ParseDuration(v)
+			return err
+		})
+	case ChaosRate, ChaosLimit:
+		r.Value, err = ask("Request count", "3", validCount)
+	}
+
+	if err != nil {
+		return r, err
+	}
+
+	status, err := ask("Status of successful responses", "200", validStatus)
+
+	if err != nil {
+		return r, err
+	}
+
+	r.Status, _ = strconv.Atoi(status)
+
+	if r.Chaos == ChaosRate || r.Chaos == ChaosLimit {
+
+		failure, err := ask("Status of failed responses", "500", validStatus)
+
+		if err != nil {
+			return r, err
+		}
+
+		r.Failure, _ = strconv.Atoi(failure)
+
+		r.Recover = confirm("Switch the route to a healthy scenario after its first failure (direct)")
+	}
+
+	return r, nil
+}
+
+func ask(label, value string, validate promptui.ValidateFunc) (string, error) {
+
+	prompt := promptui.Prompt{
+		Label:    label,
+		Default:  value,
+		Validate: validate,
+	}
+
+	result, err := prompt.Run()
+
+	if err != nil {
+		return "", errors.Wrap(err, "Scenario wizard aborted")
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+func confirm(message string) bool {
+
+	prompt := promptui.Select{
+		Label: message + " [Y/N]",
+		Items: []string{"Y", "N"},
+	}
+
+	_, result, err := prompt.Run()
+
+	return err == nil && result == "Y"
+}
+
+func validCount(v string) error {
+
+	n, err := strconv.Atoi(v)
+
+	if err != nil || n < 1 {
+		return errors.New("Count must be a positive number")
+	}
+
+	return nil
+}
+
+// validPath rejects paths defined before in the service, whatever their method, as a service maps every path to one route.
+func validPath(routes map[string]bool) promptui.ValidateFunc {
+	return func(v string) error {
+		if !strings.HasPrefix(v, "/") {
+			return errors.New("Path must start with /")
+		}
+
+		if routes[v] {
+			return errors.Errorf("%s is already defined", v)
+		}
+
+		return nil
+	}
+}
+
+func validStatus(v string) error {
+
+	n, err := strconv.Atoi(v)
+
+	if err != nil || n < 100 || n > 599 {
+		return errors.New("Status must be between 100 and 599")
+	}
+
+	return nil
+}